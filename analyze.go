@@ -1,37 +1,332 @@
 package tinybird
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/url"
+	"os"
+	"path"
+	"strings"
 )
 
-func (c *ClientImpl) Analyze(ctx context.Context, input interface{}) (*AnalyzeResponse, error) {
-	baseUrl := fmt.Sprintf("%s://%s/%s/analyze",
-		c.options.Protocol,
-		c.options.Host,
-		c.options.ApiVersion,
-	)
+// DefaultAnalyzeSampleBytes is the most CSV or NDJSON data Analyze reads from a
+// reader, file or path unless AnalyzeOptions.SampleBytes says otherwise. The API
+// only guesses the schema from a sample, so uploading whole files costs time
+// without improving the result. Byte slices are sent whole unless SampleBytes is set.
+const DefaultAnalyzeSampleBytes = 1 << 20
 
-	var response AnalyzeResponse
+// Formats of the data passed to Analyze
+const (
+	AnalyzeFormatCSV     = "csv"
+	AnalyzeFormatNDJSON  = "ndjson"
+	AnalyzeFormatParquet = "parquet"
+)
+
+// sniffBytes is how much of the input is read to detect its format
+const sniffBytes = 512
+
+func (c *ClientImpl) Analyze(ctx context.Context, input interface{}, callOptions ...CallOption) (*AnalyzeResponse, error) {
+	return c.AnalyzeWithOptions(ctx, input, nil, callOptions...)
+}
+
+func (c *ClientImpl) AnalyzeWithOptions(ctx context.Context, input interface{}, options *AnalyzeOptions, callOptions ...CallOption) (*AnalyzeResponse, error) {
+	ctx, cancel := newCallOptions(callOptions).context(ctx)
+	defer cancel()
+
+	// Without options, byte slices are uploaded as is, as Analyze always did
+	if data, ok := input.([]byte); ok && options == nil {
+		var response AnalyzeResponse
+		if err := c.httpClient.PostMultipart(ctx, c.apiURL("analyze"), "file", "data", data, &response); err != nil {
+			return nil, err
+		}
+		return &response, nil
+	}
+
+	if options == nil {
+		options = &AnalyzeOptions{}
+	}
 
 	switch v := input.(type) {
 	case []byte:
-		// Local file upload via multipart form
-		err := c.httpClient.PostMultipart(ctx, baseUrl, "file", "data", v, &response)
+		return c.analyzeReader(ctx, bytes.NewReader(v), "", options, -1)
+	case string:
+		if strings.Contains(v, "://") {
+			return c.analyzeURL(ctx, v, options)
+		}
+		f, err := os.Open(v)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to open file to analyze: %w", err)
 		}
-	case string:
-		// Remote URL analysis
-		reqUrl := baseUrl + "?url=" + url.QueryEscape(v)
-		err := c.httpClient.PostRaw(ctx, reqUrl, nil, "", "", &response)
+		defer f.Close()
+		return c.analyzeReader(ctx, f, v, options, DefaultAnalyzeSampleBytes)
+	case *os.File:
+		return c.analyzeReader(ctx, v, v.Name(), options, DefaultAnalyzeSampleBytes)
+	case io.Reader:
+		return c.analyzeReader(ctx, v, "", options, DefaultAnalyzeSampleBytes)
+	default:
+		return nil, fmt.Errorf("unsupported input type: expected []byte, string, *os.File or io.Reader, got %T", input)
+	}
+}
+
+// analyzeURL asks the API to fetch and analyze a remote file
+func (c *ClientImpl) analyzeURL(ctx context.Context, fileURL string, options *AnalyzeOptions) (*AnalyzeResponse, error) {
+	format := options.Format
+	if parsed, err := url.Parse(fileURL); err == nil {
+		options = withNameHints(options, parsed.Path)
+		if format == "" {
+			format = formatFromName(parsed.Path)
+		}
+	}
+
+	params := analyzeParams(options, format)
+	params["url"] = fileURL
+
+	var response AnalyzeResponse
+	err := c.httpClient.PostRaw(ctx, withQueryParams(c.apiURL("analyze"), params), nil, "", "", &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// analyzeReader uploads a sample of CSV and NDJSON data, or the whole of Parquet
+// data. defaultSample is the sample size used when options do not set one, -1 for
+// the whole data.
+func (c *ClientImpl) analyzeReader(ctx context.Context, r io.Reader, name string, options *AnalyzeOptions, defaultSample int) (*AnalyzeResponse, error) {
+	options = withNameHints(options, name)
+
+	format := options.Format
+	if format == "" {
+		format = formatFromName(name)
+	}
+	if format == "" {
+		head, rest, err := peek(r, sniffBytes)
 		if err != nil {
+			return nil, fmt.Errorf("failed to read data to analyze: %w", err)
+		}
+		format, r = sniffFormat(head), rest
+	}
+
+	reqURL := withQueryParams(c.apiURL("analyze"), analyzeParams(options, format))
+	fileName := "data." + format
+
+	var response AnalyzeResponse
+
+	// Parquet metadata is at the end of the file, so it cannot be truncated
+	if format == AnalyzeFormatParquet {
+		if err := c.postMultipartStream(ctx, reqURL, "file", fileName, r, &response); err != nil {
 			return nil, err
 		}
-	default:
-		return nil, fmt.Errorf("unsupported input type: expected []byte or string, got %T", input)
+		return &response, nil
+	}
+
+	sample, err := sampleRows(r, format, options, defaultSample)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.httpClient.PostMultipart(ctx, reqURL, "file", fileName, sample, &response); err != nil {
+		return nil, err
 	}
 
 	return &response, nil
 }
+
+// postMultipartStream sends r as a multipart file upload without reading it into memory
+func (c *ClientImpl) postMultipartStream(ctx context.Context, reqURL string, fieldName string, fileName string, r io.Reader, result interface{}) error {
+	source, err := newReplayableSource(r)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	// Every attempt must use the boundary of the Content-Type header
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+
+	getBody := func() (io.ReadCloser, error) {
+		src, err := source.open()
+		if err != nil {
+			return nil, err
+		}
+
+		body, done := multipartStream(src, boundary, fieldName, fileName)
+		source.track(done)
+
+		return body, nil
+	}
+
+	return c.httpClient.PostStream(ctx, reqURL, getBody, "multipart/form-data; boundary="+boundary, "", result)
+}
+
+// multipartStream returns a reader producing a multipart form with src as its only file.
+// The returned channel is closed once src is no longer read.
+func multipartStream(src io.Reader, boundary string, fieldName string, fileName string) (io.ReadCloser, <-chan struct{}) {
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		writer := multipart.NewWriter(pw)
+		err := writer.SetBoundary(boundary)
+		var part io.Writer
+		if err == nil {
+			part, err = writer.CreateFormFile(fieldName, fileName)
+		}
+		if err == nil {
+			_, err = io.Copy(part, src)
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+
+		if err != nil {
+			pw.CloseWithError(fmt.Errorf("failed to write multipart body: %w", err))
+			return
+		}
+		pw.Close()
+	}()
+
+	return pr, done
+}
+
+// analyzeParams returns the query parameters carrying the format and CSV dialect hints
+func analyzeParams(options *AnalyzeOptions, format string) map[string]string {
+	params := map[string]string{}
+	if options.Format != "" {
+		params["format"] = options.Format
+	}
+	if format == AnalyzeFormatCSV || format == "" {
+		for key, value := range map[string]string{
+			"dialect_delimiter":  options.Delimiter,
+			"dialect_new_line":   options.NewLine,
+			"dialect_escapechar": options.EscapeChar,
+		} {
+			if value != "" {
+				params[key] = value
+			}
+		}
+	}
+	return params
+}
+
+// sampleRows reads complete rows of CSV or NDJSON data, up to the sample limits
+func sampleRows(r io.Reader, format string, options *AnalyzeOptions, defaultSample int) ([]byte, error) {
+	limit := options.SampleBytes
+	if limit == 0 {
+		limit = defaultSample
+	}
+
+	var data []byte
+	var err error
+	if limit < 0 {
+		data, err = io.ReadAll(r)
+	} else {
+		data, err = io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data to analyze: %w", err)
+	}
+
+	truncated := limit >= 0 && len(data) > limit
+	if truncated {
+		data = data[:limit]
+	}
+
+	newline := byte('\n')
+	if options.NewLine != "" {
+		newline = options.NewLine[len(options.NewLine)-1]
+	}
+
+	// Newlines inside quoted CSV fields do not end a row
+	var escape byte
+	if options.EscapeChar != "" {
+		escape = options.EscapeChar[0]
+	}
+	quoted := false
+	rows, end := 0, 0
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; {
+		case format == AnalyzeFormatCSV && quoted && escape != 0 && c == escape && escape != '"':
+			i++
+		case format == AnalyzeFormatCSV && c == '"':
+			quoted = !quoted
+		case c == newline && !quoted:
+			rows++
+			end = i + 1
+			if options.SampleRows > 0 && rows == options.SampleRows {
+				return data[:end], nil
+			}
+		}
+	}
+
+	if !truncated {
+		return data, nil
+	}
+	if end == 0 {
+		return nil, fmt.Errorf("no complete row in the first %d bytes; increase AnalyzeOptions.SampleBytes", limit)
+	}
+	return data[:end], nil
+}
+
+// withNameHints returns options with the CSV dialect implied by a file name,
+// a tab delimiter for .tsv files, unless options already set it
+func withNameHints(options *AnalyzeOptions, name string) *AnalyzeOptions {
+	if options.Delimiter != "" || strings.ToLower(path.Ext(name)) != ".tsv" {
+		return options
+	}
+
+	hinted := *options
+	hinted.Delimiter = "\t"
+	return &hinted
+}
+
+// formatFromName returns the format implied by a file extension, empty if unknown
+func formatFromName(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".csv", ".tsv":
+		return AnalyzeFormatCSV
+	case ".ndjson", ".jsonl", ".json":
+		return AnalyzeFormatNDJSON
+	case ".parquet":
+		return AnalyzeFormatParquet
+	}
+	return ""
+}
+
+// sniffFormat guesses the format from the first bytes of the data
+func sniffFormat(head []byte) string {
+	if bytes.HasPrefix(head, []byte("PAR1")) {
+		return AnalyzeFormatParquet
+	}
+
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	head = bytes.TrimLeft(head, " \t\r\n")
+	if len(head) > 0 && (head[0] == '{' || head[0] == '[') {
+		return AnalyzeFormatNDJSON
+	}
+	return AnalyzeFormatCSV
+}
+
+// peek returns up to n bytes from the start of r, and a reader that still starts at them
+func peek(r io.Reader, n int) ([]byte, io.Reader, error) {
+	head := make([]byte, n)
+	read, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, nil, err
+	}
+	head = head[:read]
+
+	// Rewinding keeps seekable readers, such as files, seekable for retries
+	if seeker, ok := r.(io.Seeker); ok {
+		if _, err := seeker.Seek(int64(-read), io.SeekCurrent); err == nil {
+			return head, r, nil
+		}
+	}
+
+	return head, io.MultiReader(bytes.NewReader(head), r), nil
+}
@@ -0,0 +1,121 @@
+package tinybird
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+
+	"github.com/NOLLYWOOD-COM/tinybird/internal/codegen"
+	"github.com/NOLLYWOOD-COM/tinybird/internal/datafile"
+)
+
+// DefaultDatasourceEngine is the engine of datasource files generated from an analysis.
+const DefaultDatasourceEngine = "MergeTree"
+
+// GoStruct returns a Go file in package packageName declaring a struct named
+// typeName with a field for each analyzed column, tagged with the column name.
+// When the JSONPaths nest fields, for example $.user.id, the struct gets a
+// MarshalJSON method building the nested object so rows can be sent with
+// SendEvents as is. The file imports what it uses, such as time and chtypes for
+// DateTime and Decimal fields, and compiles without edits.
+func (r *AnalyzeResponse) GoStruct(packageName string, typeName string) (string, error) {
+	if !token.IsIdentifier(packageName) {
+		return "", fmt.Errorf("invalid Go package name %q", packageName)
+	}
+	if !token.IsIdentifier(typeName) {
+		return "", fmt.Errorf("invalid Go type name %q", typeName)
+	}
+
+	columns, err := r.datafileColumns()
+	if err != nil {
+		return "", err
+	}
+
+	source, err := codegen.Struct(packageName, typeName, fmt.Sprintf("%s is a row of the analyzed data.", typeName), columns)
+	if err != nil {
+		return "", err
+	}
+
+	return string(source), nil
+}
+
+// DatasourceFile returns the text of a .datasource file for the analyzed columns,
+// with engine settings from options. Without a sorting key, the datasource is
+// sorted by, and partitioned monthly on, its first non-nullable date column.
+func (r *AnalyzeResponse) DatasourceFile(options *DatasourceFileOptions) (string, error) {
+	if options == nil {
+		options = &DatasourceFileOptions{}
+	}
+
+	columns, err := r.datafileColumns()
+	if err != nil {
+		return "", err
+	}
+
+	settings := map[string]string{"ENGINE": DefaultDatasourceEngine}
+	if options.Engine != "" {
+		settings["ENGINE"] = options.Engine
+	}
+	for keyword, value := range options.Settings {
+		settings[keyword] = value
+	}
+
+	// Keys given in Settings count as set, so they are neither inferred nor overwritten
+	sortingKey, partitionKey := options.SortingKey, options.PartitionKey
+	if sortingKey == "" && settings["ENGINE_SORTING_KEY"] == "" {
+		if column := firstDateColumn(columns); column != "" {
+			sortingKey = column
+			if partitionKey == "" && settings["ENGINE_PARTITION_KEY"] == "" {
+				partitionKey = "toYYYYMM(" + column + ")"
+			}
+		}
+	}
+	for keyword, value := range map[string]string{
+		"ENGINE_SORTING_KEY":   sortingKey,
+		"ENGINE_PARTITION_KEY": partitionKey,
+		"ENGINE_TTL":           options.TTL,
+	} {
+		if value != "" {
+			settings[keyword] = value
+		}
+	}
+
+	datasource := &datafile.Datasource{
+		Description: options.Description,
+		Columns:     columns,
+		Settings:    settings,
+	}
+
+	return datasource.Format(), nil
+}
+
+// datafileColumns returns the analyzed columns as datasource schema columns
+func (r *AnalyzeResponse) datafileColumns() ([]datafile.Column, error) {
+	if len(r.Analysis.Columns) == 0 {
+		return nil, fmt.Errorf("analysis has no columns")
+	}
+
+	columns := make([]datafile.Column, len(r.Analysis.Columns))
+	for i, column := range r.Analysis.Columns {
+		if column.Name == "" || column.RecommendedType == "" {
+			return nil, fmt.Errorf("analyzed column %d has no name or type", i)
+		}
+		columns[i] = datafile.Column{
+			Name:     column.Name,
+			Type:     column.RecommendedType,
+			JSONPath: column.Path,
+		}
+	}
+
+	return columns, nil
+}
+
+// firstDateColumn returns the first non-nullable Date or DateTime column, or an empty string
+func firstDateColumn(columns []datafile.Column) string {
+	for _, column := range columns {
+		if strings.HasPrefix(column.Type, "Date") {
+			return column.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,140 @@
+package tinybird
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func analyzedEvents() *AnalyzeResponse {
+	return &AnalyzeResponse{
+		Analysis: Analysis{
+			Columns: []ColumnAnalysis{
+				{Path: "$.timestamp", RecommendedType: "DateTime64(3)", Name: "timestamp"},
+				{Path: "$.user.id", RecommendedType: "String", Name: "user_id"},
+				{Path: "$.user.plan", RecommendedType: "LowCardinality(Nullable(String))", Name: "user_plan"},
+				{Path: "$.tags[:]", RecommendedType: "Array(String)", Name: "tags"},
+				{Path: "$.amount", RecommendedType: "Nullable(Float64)", Name: "amount"},
+			},
+		},
+	}
+}
+
+func TestAnalyzeResponse_GoStruct(t *testing.T) {
+	source, err := analyzedEvents().GoStruct("events", "Event")
+	if err != nil {
+		t.Fatalf("GoStruct() error: %v", err)
+	}
+
+	code := strings.Join(strings.Fields(source), " ")
+	for _, expected := range []string{
+		"package events",
+		`import ( "encoding/json" "time" )`,
+		"type Event struct",
+		"Timestamp time.Time `json:\"timestamp\"`",
+		"UserPlan *string `json:\"user_plan\"`",
+		"Tags []string `json:\"tags\"`",
+		"Amount *float64 `json:\"amount\"`",
+		"func (r Event) MarshalJSON() ([]byte, error)",
+		`"user": map[string]interface{}{ "id": r.UserID, "plan": r.UserPlan, }`,
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("GoStruct() does not contain %q:\n%s", expected, source)
+		}
+	}
+
+	// The file parses on its own
+	if _, err := parser.ParseFile(token.NewFileSet(), "event.go", source, 0); err != nil {
+		t.Errorf("GoStruct() is not a valid Go file: %v\n%s", err, source)
+	}
+
+	if _, err := analyzedEvents().GoStruct("events", "not a name"); err == nil {
+		t.Error("expected an error for an invalid type name")
+	}
+	if _, err := (&AnalyzeResponse{}).GoStruct("events", "Event"); err == nil {
+		t.Error("expected an error for an empty analysis")
+	}
+	if _, err := analyzedEvents().GoStruct("", "Event"); err == nil {
+		t.Error("expected an error for an empty package name")
+	}
+}
+
+func TestAnalyzeResponse_DatasourceFile(t *testing.T) {
+	text, err := analyzedEvents().DatasourceFile(&DatasourceFileOptions{
+		Description: "Events from the tracker",
+		TTL:         "toDateTime(timestamp) + INTERVAL 90 DAY",
+	})
+	if err != nil {
+		t.Fatalf("DatasourceFile() error: %v", err)
+	}
+
+	expected := "DESCRIPTION >\n" +
+		"    Events from the tracker\n" +
+		"\n" +
+		"SCHEMA >\n" +
+		"    `timestamp` DateTime64(3) `json:$.timestamp`,\n" +
+		"    `user_id` String `json:$.user.id`,\n" +
+		"    `user_plan` LowCardinality(Nullable(String)) `json:$.user.plan`,\n" +
+		"    `tags` Array(String) `json:$.tags[:]`,\n" +
+		"    `amount` Nullable(Float64) `json:$.amount`\n" +
+		"\n" +
+		"ENGINE \"MergeTree\"\n" +
+		"ENGINE_PARTITION_KEY \"toYYYYMM(timestamp)\"\n" +
+		"ENGINE_SORTING_KEY \"timestamp\"\n" +
+		"ENGINE_TTL \"toDateTime(timestamp) + INTERVAL 90 DAY\"\n"
+	if text != expected {
+		t.Errorf("DatasourceFile() =\n%s\nwant\n%s", text, expected)
+	}
+}
+
+func TestAnalyzeResponse_DatasourceFileEngineOptions(t *testing.T) {
+	text, err := analyzedEvents().DatasourceFile(&DatasourceFileOptions{
+		Engine:     "ReplacingMergeTree",
+		SortingKey: "user_id",
+		Settings:   map[string]string{"ENGINE_VER": "timestamp"},
+	})
+	if err != nil {
+		t.Fatalf("DatasourceFile() error: %v", err)
+	}
+
+	for _, expected := range []string{`ENGINE "ReplacingMergeTree"`, `ENGINE_SORTING_KEY "user_id"`, `ENGINE_VER "timestamp"`} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("DatasourceFile() does not contain %s:\n%s", expected, text)
+		}
+	}
+	if strings.Contains(text, "ENGINE_PARTITION_KEY") {
+		t.Errorf("unexpected default partition key with an explicit sorting key:\n%s", text)
+	}
+}
+
+func TestAnalyzeResponse_DatasourceFileSettingsKeys(t *testing.T) {
+	text, err := analyzedEvents().DatasourceFile(&DatasourceFileOptions{
+		Settings: map[string]string{
+			"ENGINE_SORTING_KEY":   "user_id, timestamp",
+			"ENGINE_PARTITION_KEY": "toYear(timestamp)",
+		},
+	})
+	if err != nil {
+		t.Fatalf("DatasourceFile() error: %v", err)
+	}
+
+	for _, expected := range []string{`ENGINE_SORTING_KEY "user_id, timestamp"`, `ENGINE_PARTITION_KEY "toYear(timestamp)"`} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("DatasourceFile() does not contain %s:\n%s", expected, text)
+		}
+	}
+
+	// A partition key alone still lets the sorting key be inferred
+	text, err = analyzedEvents().DatasourceFile(&DatasourceFileOptions{
+		Settings: map[string]string{"ENGINE_PARTITION_KEY": "toYear(timestamp)"},
+	})
+	if err != nil {
+		t.Fatalf("DatasourceFile() error: %v", err)
+	}
+	for _, expected := range []string{`ENGINE_SORTING_KEY "timestamp"`, `ENGINE_PARTITION_KEY "toYear(timestamp)"`} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("DatasourceFile() does not contain %s:\n%s", expected, text)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package tinybird
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAnalyze_BytesUploadedAsIs(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	data := []byte("{\"name\":\"Alice\"}\n{\"name\":\"Bob\"}")
+	mockClient.On("PostMultipart",
+		mock.Anything,
+		"https://api.tinybird.co/v0/analyze",
+		"file",
+		"data",
+		data,
+		mock.AnythingOfType("*tinybird.AnalyzeResponse"),
+	).Return(nil)
+
+	if _, err := client.Analyze(context.Background(), data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestAnalyze_BytesWithOptionsDetectsNDJSON(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	// Byte slices are not cut to DefaultAnalyzeSampleBytes
+	row := "{\"name\":\"" + strings.Repeat("a", 1000) + "\"}\n"
+	data := []byte(strings.Repeat(row, DefaultAnalyzeSampleBytes/len(row)+10))
+	mockClient.On("PostMultipart",
+		mock.Anything,
+		"https://api.tinybird.co/v0/analyze",
+		"file",
+		"data.ndjson",
+		data,
+		mock.AnythingOfType("*tinybird.AnalyzeResponse"),
+	).Return(nil)
+
+	if _, err := client.AnalyzeWithOptions(context.Background(), data, &AnalyzeOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestAnalyze_TSVFileSetsTabDelimiter(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	path := filepath.Join(t.TempDir(), "signups.tsv")
+	if err := os.WriteFile(path, []byte("id\tname\n1\tAlice\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mockClient.On("PostMultipart",
+		mock.Anything,
+		"https://api.tinybird.co/v0/analyze?dialect_delimiter=%09",
+		"file",
+		"data.csv",
+		[]byte("id\tname\n1\tAlice\n"),
+		mock.Anything,
+	).Return(nil)
+
+	if _, err := client.Analyze(context.Background(), path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestAnalyze_FilePathSamplesRows(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	path := filepath.Join(t.TempDir(), "signups.csv")
+	data := "id;comment\n1;\"multi\nline\"\n2;plain\n3;dropped\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mockClient.On("PostMultipart",
+		mock.Anything,
+		"https://api.tinybird.co/v0/analyze?dialect_delimiter=%3B",
+		"file",
+		"data.csv",
+		[]byte("id;comment\n1;\"multi\nline\"\n2;plain\n"),
+		mock.Anything,
+	).Return(nil)
+
+	_, err := client.AnalyzeWithOptions(context.Background(), path, &AnalyzeOptions{
+		SampleRows: 3,
+		Delimiter:  ";",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestAnalyze_ReaderSampleBytes(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	line := `{"id":1,"name":"row"}` + "\n"
+	input := strings.NewReader(strings.Repeat(line, 100))
+
+	var sample []byte
+	mockClient.On("PostMultipart", mock.Anything, mock.Anything, "file", "data.ndjson", mock.Anything, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			sample = args.Get(4).([]byte)
+		})
+
+	_, err := client.AnalyzeWithOptions(context.Background(), io.Reader(input), &AnalyzeOptions{
+		SampleBytes: 3*len(line) + 5,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The sample ends at the last complete row
+	if string(sample) != strings.Repeat(line, 3) {
+		t.Errorf("sample = %q", sample)
+	}
+}
+
+func TestAnalyze_SampleBytesWithoutCompleteRow(t *testing.T) {
+	client := newTestClient(NewMockHttpClient())
+
+	_, err := client.AnalyzeWithOptions(context.Background(), []byte(`{"id":1}`+"\n"), &AnalyzeOptions{SampleBytes: 4})
+	if err == nil || !strings.Contains(err.Error(), "no complete row") {
+		t.Fatalf("expected a sample size error, got %v", err)
+	}
+}
+
+func TestAnalyze_RemoteURLWithHints(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockClient.On("PostRaw",
+		mock.Anything,
+		"https://api.tinybird.co/v0/analyze?dialect_delimiter=%7C&format=csv&url=https%3A%2F%2Fexample.com%2Fexport",
+		[]byte(nil),
+		"",
+		"",
+		mock.AnythingOfType("*tinybird.AnalyzeResponse"),
+	).Return(nil)
+
+	_, err := client.AnalyzeWithOptions(context.Background(), "https://example.com/export", &AnalyzeOptions{
+		Format:    AnalyzeFormatCSV,
+		Delimiter: "|",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestAnalyze_ParquetFileIsStreamed(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	content := append([]byte("PAR1"), bytes.Repeat([]byte{0x15, 0x00}, 2048)...)
+	content = append(content, []byte("PAR1")...)
+	path := filepath.Join(t.TempDir(), "export")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var getBody func() (io.ReadCloser, error)
+	mockClient.On("PostStream",
+		mock.Anything,
+		"https://api.tinybird.co/v0/analyze",
+		captureBody(&getBody),
+		mock.MatchedBy(func(contentType string) bool { return strings.HasPrefix(contentType, "multipart/form-data; boundary=") }),
+		"",
+		mock.AnythingOfType("*tinybird.AnalyzeResponse"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		_, params, err := mime.ParseMediaType(args.String(3))
+		if err != nil {
+			t.Fatalf("invalid content type: %v", err)
+		}
+
+		// Simulate a retry: the whole file is sent on every attempt
+		for attempt := 0; attempt < 2; attempt++ {
+			reader := multipart.NewReader(bytes.NewReader(readBody(t, getBody)), params["boundary"])
+			part, err := reader.NextPart()
+			if err != nil {
+				t.Fatalf("attempt %d: failed to read part: %v", attempt, err)
+			}
+			if part.FormName() != "file" || part.FileName() != "data.parquet" {
+				t.Errorf("attempt %d: unexpected part %s %s", attempt, part.FormName(), part.FileName())
+			}
+			uploaded, _ := io.ReadAll(part)
+			if !bytes.Equal(uploaded, content) {
+				t.Errorf("attempt %d: uploaded %d bytes, want %d", attempt, len(uploaded), len(content))
+			}
+		}
+	})
+
+	if _, err := client.AnalyzeWithOptions(context.Background(), f, &AnalyzeOptions{SampleBytes: 16}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestAnalyze_UnsupportedInput(t *testing.T) {
+	client := newTestClient(NewMockHttpClient())
+
+	if _, err := client.Analyze(context.Background(), 42); err == nil {
+		t.Fatal("expected an error for an unsupported input type")
+	}
+}
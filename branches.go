@@ -0,0 +1,93 @@
+package tinybird
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// BranchesClient manages branches, isolated copies of the workspace for testing.
+type BranchesClient interface {
+	// Create creates a branch from the main workspace.
+	//
+	// options: Optional data copy and whether to wait for the branch to be ready.
+	Create(ctx context.Context, name string, options *BranchOptions) (*Branch, error)
+	// List returns the branches of the workspace.
+	List(ctx context.Context) ([]Branch, error)
+	// Get returns a branch by name.
+	Get(ctx context.Context, name string) (*Branch, error)
+	// Delete deletes a branch by ID.
+	Delete(ctx context.Context, branchID string) error
+}
+
+type branchesClient struct {
+	client *ClientImpl
+}
+
+func (c *ClientImpl) Branches() BranchesClient {
+	return &branchesClient{client: c}
+}
+
+func (b *branchesClient) Create(ctx context.Context, name string, options *BranchOptions) (*Branch, error) {
+	if options == nil {
+		options = &BranchOptions{}
+	}
+
+	params := map[string]string{"name": name}
+	if options.LastPartition {
+		params["data"] = "last_partition"
+	}
+
+	var response struct {
+		Job       *jobResponse `json:"job"`
+		Workspace Branch       `json:"workspace"`
+	}
+
+	reqUrl := withQueryParams(b.client.apiURL("environments"), params)
+	if err := b.client.httpClient.PostRaw(ctx, reqUrl, nil, "", "", &response); err != nil {
+		return nil, err
+	}
+
+	if !options.Wait || response.Job == nil {
+		branch := response.Workspace
+		return &branch, nil
+	}
+
+	if _, err := b.client.WaitForJob(ctx, response.Job.job().ID, options.PollInterval); err != nil {
+		return nil, err
+	}
+
+	// The branch is listed with its token once it is ready
+	return b.Get(ctx, name)
+}
+
+func (b *branchesClient) List(ctx context.Context) ([]Branch, error) {
+	var response struct {
+		Environments []Branch `json:"environments"`
+	}
+
+	if err := b.client.httpClient.Get(ctx, b.client.apiURL("environments"), nil, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Environments, nil
+}
+
+func (b *branchesClient) Get(ctx context.Context, name string) (*Branch, error) {
+	branches, err := b.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range branches {
+		if branches[i].Name == name {
+			return &branches[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("branch %s not found", name)
+}
+
+func (b *branchesClient) Delete(ctx context.Context, branchID string) error {
+	return b.client.httpClient.Delete(ctx, b.client.apiURL("environments/"+url.PathEscape(branchID)), nil, nil)
+}
@@ -0,0 +1,115 @@
+package tinybird
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NOLLYWOOD-COM/tinybird/internal/httpclient"
+	"github.com/stretchr/testify/mock"
+)
+
+func mockBranchList(mockClient *MockHttpClient, branches ...Branch) {
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/environments",
+		mock.Anything,
+		mock.Anything,
+	).Return(nil).Run(func(args mock.Arguments) {
+		response := args.Get(3).(*struct {
+			Environments []Branch `json:"environments"`
+		})
+		response.Environments = branches
+	})
+}
+
+func TestBranches_CreateAndWait(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockClient.On("PostRaw",
+		mock.Anything,
+		"https://api.tinybird.co/v0/environments?data=last_partition&name=ci_1234",
+		[]byte(nil),
+		"",
+		"",
+		mock.Anything,
+	).Return(nil).Run(func(args mock.Arguments) {
+		response := args.Get(5).(*struct {
+			Job       *jobResponse `json:"job"`
+			Workspace Branch       `json:"workspace"`
+		})
+		response.Job = &jobResponse{Job: Job{JobID: "job-1", Status: JobStatusWaiting}}
+		response.Workspace = Branch{ID: "b-1", Name: "ci_1234"}
+	})
+
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/jobs/job-1",
+		mock.Anything,
+		mock.AnythingOfType("*tinybird.Job"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(3).(*Job) = Job{ID: "job-1", Status: JobStatusDone}
+	})
+
+	mockBranchList(mockClient,
+		Branch{ID: "b-0", Name: "other", Token: "other-token"},
+		Branch{ID: "b-1", Name: "ci_1234", Token: "branch-token"},
+	)
+
+	branch, err := client.Branches().Create(context.Background(), "ci_1234", &BranchOptions{
+		LastPartition: true,
+		Wait:          true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if branch.Token != "branch-token" {
+		t.Errorf("Token = %q, want the ready branch's token", branch.Token)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestForBranch_ScopesRequests(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	var tokens []string
+	mockClient.On("Get",
+		mock.Anything,
+		"https://branch.tinybird.co/v0/pipes/top_pages",
+		mock.Anything,
+		mock.Anything,
+	).Return(nil).Run(func(args mock.Arguments) {
+		tokens = append(tokens, httpclient.RequestOptionsFrom(args.Get(0).(context.Context)).Token)
+	})
+
+	branchClient, err := client.ForBranch(&Branch{Name: "ci", Token: "branch-token", Host: "branch.tinybird.co"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	branchClient.CallEndpoint(context.Background(), "top_pages", nil)
+	branchClient.CallEndpoint(context.Background(), "top_pages", nil, WithToken("tenant-jwt"))
+
+	if len(tokens) != 2 || tokens[0] != "branch-token" || tokens[1] != "tenant-jwt" {
+		t.Errorf("tokens = %v, want the branch token unless overridden per call", tokens)
+	}
+
+	if _, err := client.ForBranch(&Branch{Name: "pending"}); err == nil {
+		t.Error("expected an error for a branch without a token")
+	}
+}
+
+func TestBranches_Delete(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockClient.On("Delete", mock.Anything, "https://api.tinybird.co/v0/environments/b-1", mock.Anything, nil).Return(nil)
+
+	if err := client.Branches().Delete(context.Background(), "b-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,271 @@
+package tinybird
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCacheMiss is returned by Cache and RedisClient implementations when a key is not present.
+var ErrCacheMiss = errors.New("cache miss")
+
+// Cache is a backend storing encoded endpoint responses. Implementations must be
+// safe for concurrent use and return ErrCacheMiss for unknown or expired keys.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// CacheResult describes how a CallEndpoint call was served
+type CacheResult int
+
+const (
+	CacheHit    CacheResult = iota // Served from a fresh cache entry
+	CacheStale                     // Served from a stale entry while it is refreshed in the background
+	CacheMiss                      // Fetched from the API and stored
+	CacheBypass                    // Fetched from the API without consulting the cache
+	CacheError                     // The cache backend failed, the response was fetched from the API
+)
+
+func (r CacheResult) String() string {
+	switch r {
+	case CacheHit:
+		return "hit"
+	case CacheStale:
+		return "stale"
+	case CacheMiss:
+		return "miss"
+	case CacheBypass:
+		return "bypass"
+	case CacheError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// CacheEvent is passed to CacheOptions.OnEvent for every cacheable call
+type CacheEvent struct {
+	Endpoint string      // Name of the endpoint called
+	Key      string      // Cache key of the call
+	Result   CacheResult // How the call was served
+	Err      error       // Backend error, set for CacheError and failed background refreshes
+}
+
+type cacheBypassKey struct{}
+
+// WithCacheBypass returns a context for which CallEndpoint skips the response cache.
+// The fresh response is still stored for later calls.
+func WithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+// cachedResponse is the value stored in the cache backend
+type cachedResponse struct {
+	Response *EndpointResponse `json:"response"`
+	Expires  time.Time         `json:"expires"`
+}
+
+// endpointCache serves CallEndpoint responses from a Cache backend
+type endpointCache struct {
+	backend              Cache
+	ttl                  time.Duration
+	endpointTTLs         map[string]time.Duration
+	staleWhileRevalidate time.Duration
+	onEvent              func(CacheEvent)
+	now                  func() time.Time
+
+	// Keys being refreshed in the background, so a stale entry is refreshed once
+	refreshing sync.Map
+
+	hits     atomic.Uint64
+	stale    atomic.Uint64
+	misses   atomic.Uint64
+	bypassed atomic.Uint64
+	errors   atomic.Uint64
+}
+
+func newEndpointCache(options *CacheOptions) *endpointCache {
+	backend := options.Backend
+	if backend == nil {
+		backend = NewMemoryCache(0)
+	}
+
+	return &endpointCache{
+		backend:              backend,
+		ttl:                  options.TTL,
+		endpointTTLs:         options.EndpointTTLs,
+		staleWhileRevalidate: options.StaleWhileRevalidate,
+		onEvent:              options.OnEvent,
+		now:                  time.Now,
+	}
+}
+
+// ttlFor returns how long responses of an endpoint stay fresh, 0 if they are not cached
+func (c *endpointCache) ttlFor(endpointName string) time.Duration {
+	ttl := c.ttl
+	if endpointTTL, ok := c.endpointTTLs[endpointName]; ok {
+		ttl = endpointTTL
+	}
+	return max(ttl, 0)
+}
+
+// get returns the response for key from the cache, calling fetch on a miss.
+func (c *endpointCache) get(ctx context.Context, endpointName string, key string, fetch func(context.Context) (*EndpointResponse, error)) (*EndpointResponse, error) {
+	ttl := c.ttlFor(endpointName)
+	if ttl == 0 {
+		return fetch(ctx)
+	}
+
+	if cacheBypassed(ctx) {
+		return c.fetchAndStore(ctx, endpointName, key, ttl, CacheBypass, fetch)
+	}
+
+	value, err := c.backend.Get(ctx, key)
+	if err != nil {
+		result := CacheMiss
+		if !errors.Is(err, ErrCacheMiss) {
+			result = CacheError
+			c.report(endpointName, key, CacheError, err)
+		}
+		return c.fetchAndStore(ctx, endpointName, key, ttl, result, fetch)
+	}
+
+	var entry cachedResponse
+	if err := json.Unmarshal(value, &entry); err != nil || entry.Response == nil {
+		return c.fetchAndStore(ctx, endpointName, key, ttl, CacheMiss, fetch)
+	}
+
+	if c.now().Before(entry.Expires) {
+		c.report(endpointName, key, CacheHit, nil)
+		return entry.Response, nil
+	}
+
+	// Entries outlive their TTL by the stale-while-revalidate window only
+	c.report(endpointName, key, CacheStale, nil)
+	c.refresh(ctx, endpointName, key, ttl, fetch)
+
+	return entry.Response, nil
+}
+
+// refresh fetches a stale entry again in the background, at most once per key at a time
+func (c *endpointCache) refresh(ctx context.Context, endpointName string, key string, ttl time.Duration, fetch func(context.Context) (*EndpointResponse, error)) {
+	if _, loaded := c.refreshing.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+
+	// The refresh must outlive the call that triggered it
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		defer c.refreshing.Delete(key)
+
+		response, err := fetch(ctx)
+		if err != nil {
+			c.report(endpointName, key, CacheError, err)
+			return
+		}
+		c.store(ctx, endpointName, key, ttl, response)
+	}()
+}
+
+func (c *endpointCache) fetchAndStore(ctx context.Context, endpointName string, key string, ttl time.Duration, result CacheResult, fetch func(context.Context) (*EndpointResponse, error)) (*EndpointResponse, error) {
+	response, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if result != CacheError {
+		c.report(endpointName, key, result, nil)
+	}
+	c.store(ctx, endpointName, key, ttl, response)
+
+	return response, nil
+}
+
+func (c *endpointCache) store(ctx context.Context, endpointName string, key string, ttl time.Duration, response *EndpointResponse) {
+	value, err := json.Marshal(cachedResponse{
+		Response: response,
+		Expires:  c.now().Add(ttl),
+	})
+	if err != nil {
+		c.report(endpointName, key, CacheError, err)
+		return
+	}
+
+	if err := c.backend.Set(ctx, key, value, ttl+c.staleWhileRevalidate); err != nil {
+		c.report(endpointName, key, CacheError, err)
+	}
+}
+
+func (c *endpointCache) report(endpointName string, key string, result CacheResult, err error) {
+	switch result {
+	case CacheHit:
+		c.hits.Add(1)
+	case CacheStale:
+		c.stale.Add(1)
+	case CacheMiss:
+		c.misses.Add(1)
+	case CacheBypass:
+		c.bypassed.Add(1)
+	case CacheError:
+		c.errors.Add(1)
+	}
+
+	if c.onEvent != nil {
+		c.onEvent(CacheEvent{Endpoint: endpointName, Key: key, Result: result, Err: err})
+	}
+}
+
+func (c *endpointCache) stats() CacheStats {
+	return CacheStats{
+		Enabled:   true,
+		Hits:      c.hits.Load(),
+		StaleHits: c.stale.Load(),
+		Misses:    c.misses.Load(),
+		Bypassed:  c.bypassed.Load(),
+		Errors:    c.errors.Load(),
+	}
+}
+
+// endpointCallKey identifies a call by endpoint and canonicalised parameters, for
+// caching and coalescing. The host, API version, token and extra headers are
+// hashed in so responses are never shared between workspaces or tokens with
+// different row-level permissions.
+func endpointCallKey(options *ClientOptions, call *callOptions, endpointName string, params map[string]string) string {
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+
+	headers := url.Values{}
+	for k, v := range call.headers {
+		headers.Set(k, v)
+	}
+
+	h := sha256.New()
+	for _, part := range []string{options.Host, options.ApiVersion, call.effectiveToken(options), headers.Encode(), endpointName, values.Encode()} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	return "tinybird:endpoint:" + endpointName + ":" + hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+func (c *ClientImpl) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+	return c.cache.stats()
+}
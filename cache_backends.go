@@ -0,0 +1,157 @@
+package tinybird
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultMemoryCacheSize is the capacity of a MemoryCache created with a non-positive size.
+const DefaultMemoryCacheSize = 64 << 20
+
+// MemoryCache is an in-process Cache that evicts the least recently used entries
+// once the stored values exceed its size.
+type MemoryCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	items    map[string]*list.Element
+	lru      *list.List
+	now      func() time.Time
+}
+
+type memoryCacheItem struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache creates a MemoryCache holding up to maxBytes of encoded responses,
+// DefaultMemoryCacheSize if maxBytes is not positive.
+func NewMemoryCache(maxBytes int64) *MemoryCache {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMemoryCacheSize
+	}
+
+	return &MemoryCache{
+		maxBytes: maxBytes,
+		items:    map[string]*list.Element{},
+		lru:      list.New(),
+		now:      time.Now,
+	}
+}
+
+func (m *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.items[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+
+	item := elem.Value.(*memoryCacheItem)
+	if !m.now().Before(item.expires) {
+		m.remove(elem)
+		return nil, ErrCacheMiss
+	}
+
+	m.lru.MoveToFront(elem)
+	return item.value, nil
+}
+
+func (m *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.items[key]; ok {
+		m.remove(elem)
+	}
+
+	// Values larger than the whole cache are not worth evicting everything for
+	if int64(len(value)) > m.maxBytes {
+		return nil
+	}
+
+	m.items[key] = m.lru.PushFront(&memoryCacheItem{
+		key:     key,
+		value:   value,
+		expires: m.now().Add(ttl),
+	})
+	m.size += int64(len(value))
+
+	for m.size > m.maxBytes {
+		m.remove(m.lru.Back())
+	}
+
+	return nil
+}
+
+func (m *MemoryCache) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.items[key]; ok {
+		m.remove(elem)
+	}
+	return nil
+}
+
+// Len returns the number of entries currently stored
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lru.Len()
+}
+
+// remove drops an entry. Callers hold m.mu.
+func (m *MemoryCache) remove(elem *list.Element) {
+	item := m.lru.Remove(elem).(*memoryCacheItem)
+	delete(m.items, item.key)
+	m.size -= int64(len(item.value))
+}
+
+// RedisClient is the subset of Redis commands used by RedisCache. Wrappers around
+// go-redis, redigo or rueidis clients only need a few lines; Get must return
+// ErrCacheMiss when the key does not exist.
+type RedisClient interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	SetEx(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Del(ctx context.Context, key string) error
+}
+
+// RedisCache is a Cache stored in Redis or a Redis-compatible server, letting
+// several processes share cached responses.
+type RedisCache struct {
+	client RedisClient
+	prefix string
+}
+
+// NewRedisCache creates a RedisCache storing keys under the given prefix.
+func NewRedisCache(client RedisClient, prefix string) *RedisCache {
+	return &RedisCache{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (r *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := r.client.Get(ctx, r.prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, ErrCacheMiss
+	}
+	return value, nil
+}
+
+func (r *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.SetEx(ctx, r.prefix+key, value, ttl)
+}
+
+func (r *RedisCache) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.prefix+key)
+}
@@ -0,0 +1,232 @@
+package tinybird
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func newCachingTestClient(mockClient *MockHttpClient, options CacheOptions) *ClientImpl {
+	client := newTestClient(mockClient)
+	client.cache = newEndpointCache(&options)
+	return client
+}
+
+func TestCallEndpoint_CacheHit(t *testing.T) {
+	mockClient := NewMockHttpClient()
+
+	var events []CacheResult
+	client := newCachingTestClient(mockClient, CacheOptions{
+		TTL:     time.Minute,
+		OnEvent: func(event CacheEvent) { events = append(events, event.Result) },
+	})
+
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/pipes/top_pages",
+		mock.Anything,
+		mock.AnythingOfType("*tinybird.EndpointResponse"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(3).(*EndpointResponse).Rows = 3
+	}).Once()
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		response, err := client.CallEndpoint(ctx, "top_pages", map[string]string{"limit": "3", "day": "2024-01-01"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if response.Rows != 3 {
+			t.Errorf("Rows = %d, want 3", response.Rows)
+		}
+	}
+
+	mockClient.AssertExpectations(t)
+
+	stats := client.CacheStats()
+	if stats.Misses != 1 || stats.Hits != 2 {
+		t.Errorf("stats = %+v, want 1 miss and 2 hits", stats)
+	}
+	if len(events) != 3 || events[0] != CacheMiss || events[2] != CacheHit {
+		t.Errorf("events = %v", events)
+	}
+}
+
+func TestCallEndpoint_CacheKeepsExactNumbers(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newCachingTestClient(mockClient, CacheOptions{TTL: time.Minute})
+
+	body := []byte(`{"meta":[{"name":"hits","type":"UInt64"}],"data":[{"hits":18446744073709551615}],"rows":1}`)
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/pipes/top_pages",
+		mock.Anything,
+		mock.AnythingOfType("*tinybird.EndpointResponse"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		if err := json.Unmarshal(body, args.Get(3)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}).Once()
+
+	for i := 0; i < 2; i++ {
+		response, err := client.CallEndpoint(context.Background(), "top_pages", nil)
+		if err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+		rows, err := response.DecodedRows()
+		if err != nil {
+			t.Fatalf("call %d: DecodedRows() error: %v", i, err)
+		}
+		if rows[0]["hits"] != uint64(18446744073709551615) {
+			t.Errorf("call %d: hits = %#v", i, rows[0]["hits"])
+		}
+	}
+
+	mockClient.AssertExpectations(t)
+	if stats := client.CacheStats(); stats.Hits != 1 {
+		t.Errorf("stats = %+v, want 1 hit", stats)
+	}
+}
+
+func TestCallEndpoint_CacheBypassAndDisabledEndpoint(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newCachingTestClient(mockClient, CacheOptions{
+		TTL:          time.Minute,
+		EndpointTTLs: map[string]time.Duration{"live": 0},
+	})
+
+	mockClient.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	ctx := context.Background()
+	client.CallEndpoint(ctx, "top_pages", nil)
+	client.CallEndpoint(WithCacheBypass(ctx), "top_pages", nil)
+	client.CallEndpoint(ctx, "live", nil)
+	client.CallEndpoint(ctx, "live", nil)
+
+	mockClient.AssertNumberOfCalls(t, "Get", 4)
+
+	if stats := client.CacheStats(); stats.Bypassed != 1 || stats.Misses != 1 {
+		t.Errorf("stats = %+v, want 1 bypass and 1 miss", stats)
+	}
+}
+
+func TestCallEndpoint_StaleWhileRevalidate(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newCachingTestClient(mockClient, CacheOptions{
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: time.Hour,
+	})
+
+	now := time.Unix(1_700_000_000, 0)
+	client.cache.now = func() time.Time { return now }
+
+	var calls atomic.Int32
+	refreshed := make(chan struct{})
+	mockClient.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		n := calls.Add(1)
+		args.Get(3).(*EndpointResponse).Rows = int(n)
+		if n == 2 {
+			close(refreshed)
+		}
+	})
+
+	ctx := context.Background()
+	client.CallEndpoint(ctx, "top_pages", nil)
+
+	now = now.Add(2 * time.Minute)
+	response, err := client.CallEndpoint(ctx, "top_pages", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Rows != 1 {
+		t.Errorf("Rows = %d, want the stale response", response.Rows)
+	}
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("stale entry was not refreshed")
+	}
+
+	// Wait for the refreshed response to be stored
+	deadline := time.Now().Add(time.Second)
+	for {
+		response, _ = client.CallEndpoint(ctx, "top_pages", nil)
+		if response.Rows == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if response.Rows != 2 {
+		t.Errorf("Rows = %d, want the refreshed response", response.Rows)
+	}
+}
+
+func TestCallEndpoint_CacheDoesNotStoreErrors(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newCachingTestClient(mockClient, CacheOptions{TTL: time.Minute})
+
+	mockClient.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("boom")).Once()
+	mockClient.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+	if _, err := client.CallEndpoint(context.Background(), "top_pages", nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := client.CallEndpoint(context.Background(), "top_pages", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestEndpointCallKey(t *testing.T) {
+	options := &ClientOptions{Host: "api.tinybird.co", ApiVersion: "v0", Token: "token"}
+	call := newCallOptions(nil)
+
+	a := endpointCallKey(options, call, "top", map[string]string{"a": "1", "b": "2"})
+	b := endpointCallKey(options, call, "top", map[string]string{"b": "2", "a": "1"})
+	if a != b {
+		t.Errorf("keys differ for the same params: %s != %s", a, b)
+	}
+
+	if endpointCallKey(options, newCallOptions([]CallOption{WithToken("other-token")}), "top", map[string]string{"a": "1", "b": "2"}) == a {
+		t.Error("expected different tokens to produce different keys")
+	}
+	if endpointCallKey(options, newCallOptions([]CallOption{WithHeader("X-Tenant", "1")}), "top", map[string]string{"a": "1", "b": "2"}) == a {
+		t.Error("expected different headers to produce different keys")
+	}
+	if endpointCallKey(options, call, "top", map[string]string{"a": "1"}) == a {
+		t.Error("expected different params to produce different keys")
+	}
+}
+
+func TestMemoryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewMemoryCache(10)
+	ctx := context.Background()
+
+	cache.Set(ctx, "a", []byte("1234"), time.Minute)
+	cache.Set(ctx, "b", []byte("1234"), time.Minute)
+	cache.Get(ctx, "a")
+	cache.Set(ctx, "c", []byte("1234"), time.Minute)
+
+	if _, err := cache.Get(ctx, "b"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get(b) error = %v, want ErrCacheMiss", err)
+	}
+	if _, err := cache.Get(ctx, "a"); err != nil {
+		t.Errorf("Get(a) error = %v", err)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", cache.Len())
+	}
+
+	now := time.Now().Add(2 * time.Minute)
+	cache.now = func() time.Time { return now }
+	if _, err := cache.Get(ctx, "c"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get(c) error = %v after expiry, want ErrCacheMiss", err)
+	}
+}
@@ -0,0 +1,110 @@
+package tinybird
+
+import (
+	"context"
+	"time"
+
+	"github.com/NOLLYWOOD-COM/tinybird/internal/httpclient"
+)
+
+// CallOption overrides client settings for a single CallEndpoint, SendEvents or Analyze call.
+type CallOption func(*callOptions)
+
+// ParamMode controls how CallEndpoint passes endpoint parameters.
+type ParamMode int
+
+const (
+	ParamsAuto  ParamMode = iota // POST when the query string exceeds ClientOptions.EndpointPostThreshold
+	ParamsQuery                  // Always send parameters in the query string of a GET request
+	ParamsBody                   // Always send parameters as a JSON body of a POST request
+)
+
+type callOptions struct {
+	timeout    time.Duration
+	maxRetries *int
+	token      string
+	headers    map[string]string
+	paramMode  ParamMode
+	noCache    bool
+}
+
+// WithTimeout bounds the whole call, including retries, by the given duration.
+func WithTimeout(timeout time.Duration) CallOption {
+	return func(co *callOptions) {
+		co.timeout = timeout
+	}
+}
+
+// WithRetries overrides the client's MaxRetries for the call.
+func WithRetries(retries int) CallOption {
+	return func(co *callOptions) {
+		co.maxRetries = &retries
+	}
+}
+
+// WithToken authenticates the call with a different token, such as a per-tenant JWT.
+func WithToken(token string) CallOption {
+	return func(co *callOptions) {
+		co.token = token
+	}
+}
+
+// WithHeader adds an HTTP header to the call's requests.
+func WithHeader(name, value string) CallOption {
+	return func(co *callOptions) {
+		if co.headers == nil {
+			co.headers = map[string]string{}
+		}
+		co.headers[name] = value
+	}
+}
+
+// WithParamMode sets how CallEndpoint passes endpoint parameters.
+func WithParamMode(mode ParamMode) CallOption {
+	return func(co *callOptions) {
+		co.paramMode = mode
+	}
+}
+
+// WithoutCache makes CallEndpoint skip the response cache, like WithCacheBypass.
+func WithoutCache() CallOption {
+	return func(co *callOptions) {
+		co.noCache = true
+	}
+}
+
+func newCallOptions(options []CallOption) *callOptions {
+	co := &callOptions{}
+	for _, option := range options {
+		option(co)
+	}
+	return co
+}
+
+// context applies the call options to ctx. The returned cancel function must be called.
+func (co *callOptions) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if co.token != "" || co.maxRetries != nil || len(co.headers) > 0 {
+		ctx = httpclient.WithRequestOptions(ctx, httpclient.RequestOptions{
+			Token:      co.token,
+			MaxRetries: co.maxRetries,
+			Headers:    co.headers,
+		})
+	}
+
+	if co.noCache {
+		ctx = WithCacheBypass(ctx)
+	}
+
+	if co.timeout > 0 {
+		return context.WithTimeout(ctx, co.timeout)
+	}
+	return ctx, func() {}
+}
+
+// effectiveToken returns the token the call authenticates with
+func (co *callOptions) effectiveToken(options *ClientOptions) string {
+	if co.token != "" {
+		return co.token
+	}
+	return options.Token
+}
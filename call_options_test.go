@@ -0,0 +1,81 @@
+package tinybird
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NOLLYWOOD-COM/tinybird/internal/httpclient"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCallEndpoint_CallOptions(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	var ctx context.Context
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/pipes/top_pages",
+		mock.Anything,
+		mock.AnythingOfType("*tinybird.EndpointResponse"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		ctx = args.Get(0).(context.Context)
+	})
+
+	_, err := client.CallEndpoint(context.Background(), "top_pages", nil,
+		WithTimeout(time.Minute),
+		WithRetries(0),
+		WithToken("tenant-jwt"),
+		WithHeader("X-Request-Id", "abc"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		t.Error("expected the call context to have a deadline")
+	}
+
+	overrides := httpclient.RequestOptionsFrom(ctx)
+	if overrides.Token != "tenant-jwt" {
+		t.Errorf("Token = %q, want tenant-jwt", overrides.Token)
+	}
+	if overrides.MaxRetries == nil || *overrides.MaxRetries != 0 {
+		t.Errorf("MaxRetries = %v, want 0", overrides.MaxRetries)
+	}
+	if overrides.Headers["X-Request-Id"] != "abc" {
+		t.Errorf("Headers = %v", overrides.Headers)
+	}
+}
+
+func TestCallEndpoint_ParamsInBody(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	params := map[string]string{"ids": "1,2,3"}
+	mockClient.On("Post",
+		mock.Anything,
+		"https://api.tinybird.co/v0/pipes/top_pages",
+		params,
+		mock.AnythingOfType("*tinybird.EndpointResponse"),
+	).Return(nil)
+
+	if _, err := client.CallEndpoint(context.Background(), "top_pages", params, WithParamMode(ParamsBody)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestCallEndpoint_WithoutCache(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newCachingTestClient(mockClient, CacheOptions{TTL: time.Minute})
+
+	mockClient.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	client.CallEndpoint(context.Background(), "top_pages", nil)
+	client.CallEndpoint(context.Background(), "top_pages", nil, WithoutCache())
+
+	mockClient.AssertNumberOfCalls(t, "Get", 2)
+}
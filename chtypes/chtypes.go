@@ -0,0 +1,348 @@
+// Package chtypes parses ClickHouse type names, as reported in the meta of
+// Tinybird responses, and decodes JSON values into Go values of the matching type.
+//
+// Values decode to the Go type returned by Type.ReflectType:
+//
+//	Bool                         bool
+//	Int8 ... Int64, UInt8 ...    int8 ... int64, uint8 ... uint64
+//	Int128, Int256, UInt128 ...  *big.Int
+//	Float32, Float64             float32, float64
+//	Decimal(P, S)                Decimal
+//	String, FixedString, Enum    string
+//	UUID                         UUID
+//	IPv4, IPv6                   netip.Addr
+//	Date, DateTime, DateTime64   time.Time, in the timezone of the type or UTC
+//	Array(T)                     []T
+//	Map(K, V)                    map[K]V
+//	Tuple(T1, T2)                []interface{}
+//	Tuple(a T1, b T2)            map[string]interface{}
+//	Nullable(T)                  *T, nil for NULL; T itself if it is already nilable
+//	LowCardinality(T)            T
+//
+// Other types, such as AggregateFunction, decode to the JSON value as is.
+package chtypes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind identifies a ClickHouse type without its parameters
+type Kind int
+
+const (
+	KindUnknown Kind = iota
+	KindBool
+	KindInt8
+	KindInt16
+	KindInt32
+	KindInt64
+	KindInt128
+	KindInt256
+	KindUInt8
+	KindUInt16
+	KindUInt32
+	KindUInt64
+	KindUInt128
+	KindUInt256
+	KindFloat32
+	KindFloat64
+	KindDecimal
+	KindString
+	KindFixedString
+	KindEnum
+	KindUUID
+	KindIPv4
+	KindIPv6
+	KindDate
+	KindDate32
+	KindDateTime
+	KindDateTime64
+	KindArray
+	KindMap
+	KindTuple
+	KindNullable
+	KindLowCardinality
+	KindJSON
+)
+
+var kindNames = map[string]Kind{
+	"Bool": KindBool, "Boolean": KindBool,
+	"Int8": KindInt8, "Int16": KindInt16, "Int32": KindInt32, "Int64": KindInt64, "Int128": KindInt128, "Int256": KindInt256,
+	"UInt8": KindUInt8, "UInt16": KindUInt16, "UInt32": KindUInt32, "UInt64": KindUInt64, "UInt128": KindUInt128, "UInt256": KindUInt256,
+	"Float32": KindFloat32, "Float64": KindFloat64,
+	"Decimal": KindDecimal, "Decimal32": KindDecimal, "Decimal64": KindDecimal, "Decimal128": KindDecimal, "Decimal256": KindDecimal,
+	"String": KindString, "FixedString": KindFixedString, "Enum8": KindEnum, "Enum16": KindEnum, "Enum": KindEnum,
+	"UUID": KindUUID, "IPv4": KindIPv4, "IPv6": KindIPv6,
+	"Date": KindDate, "Date32": KindDate32, "DateTime": KindDateTime, "DateTime64": KindDateTime64,
+	"Array": KindArray, "Map": KindMap, "Tuple": KindTuple, "Nullable": KindNullable, "LowCardinality": KindLowCardinality,
+	"JSON": KindJSON, "Object": KindJSON,
+}
+
+// decimalPrecisions is the precision of the fixed-size Decimal aliases
+var decimalPrecisions = map[string]int{"Decimal32": 9, "Decimal64": 18, "Decimal128": 38, "Decimal256": 76}
+
+// Type is a parsed ClickHouse type
+type Type struct {
+	Kind Kind
+	Name string // The type name as written, e.g. DateTime64 or SimpleAggregateFunction
+
+	Elem   *Type    // Element of Array, Nullable and LowCardinality
+	Key    *Type    // Key of Map, whose element is Elem
+	Fields []*Type  // Elements of Tuple
+	Names  []string // Names of the elements of a named Tuple, nil otherwise
+
+	Precision int            // Digits of Decimal, fractional digits of DateTime64
+	Scale     int            // Fractional digits of Decimal
+	Length    int            // Bytes of FixedString
+	Location  *time.Location // Timezone of DateTime and DateTime64, nil for the server default
+
+	raw string
+}
+
+// Parse parses a type name such as Nullable(DateTime64(3, 'UTC')) or Map(String, UInt64).
+// Unknown types parse to a Type of KindUnknown.
+func Parse(s string) (*Type, error) {
+	s = strings.TrimSpace(s)
+	name, args, err := splitType(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid type %q: %w", s, err)
+	}
+
+	t := &Type{Kind: kindNames[name], Name: name, raw: s}
+
+	switch t.Kind {
+	case KindArray, KindNullable, KindLowCardinality:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("invalid type %q: %s takes one type", s, name)
+		}
+		if t.Elem, err = Parse(args[0]); err != nil {
+			return nil, err
+		}
+
+	case KindMap:
+		if len(args) != 2 {
+			return nil, fmt.Errorf("invalid type %q: Map takes a key and a value type", s)
+		}
+		if t.Key, err = Parse(args[0]); err != nil {
+			return nil, err
+		}
+		if t.Elem, err = Parse(args[1]); err != nil {
+			return nil, err
+		}
+
+	case KindTuple:
+		if err := t.parseTuple(args); err != nil {
+			return nil, fmt.Errorf("invalid type %q: %w", s, err)
+		}
+
+	case KindDecimal:
+		if err := t.parseDecimal(args); err != nil {
+			return nil, fmt.Errorf("invalid type %q: %w", s, err)
+		}
+
+	case KindFixedString:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("invalid type %q: FixedString takes a length", s)
+		}
+		if t.Length, err = strconv.Atoi(args[0]); err != nil {
+			return nil, fmt.Errorf("invalid type %q: %w", s, err)
+		}
+
+	case KindDateTime, KindDateTime64:
+		if err := t.parseDateTime(args); err != nil {
+			return nil, fmt.Errorf("invalid type %q: %w", s, err)
+		}
+
+	case KindUnknown:
+		// SimpleAggregateFunction(f, T) stores plain values of T
+		if name == "SimpleAggregateFunction" && len(args) == 2 {
+			inner, err := Parse(args[1])
+			if err != nil {
+				return nil, err
+			}
+			inner.raw = s
+			return inner, nil
+		}
+	}
+
+	return t, nil
+}
+
+// MustParse is like Parse but panics if the type cannot be parsed
+func MustParse(s string) *Type {
+	t, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// String returns the type name as it was parsed
+func (t *Type) String() string {
+	return t.raw
+}
+
+// GoType returns the Go type expression of decoded values, such as
+// *time.Time or map[string]uint64, qualified with the package names
+// chtypes, big, netip and time.
+func (t *Type) GoType() string {
+	return strings.ReplaceAll(t.ReflectType().String(), "interface {}", "interface{}")
+}
+
+// Nilable reports whether decoded values of t can be nil without a pointer
+func (t *Type) Nilable() bool {
+	switch t.Kind {
+	case KindInt128, KindInt256, KindUInt128, KindUInt256, KindArray, KindMap, KindTuple, KindJSON, KindUnknown, KindNullable:
+		return true
+	case KindLowCardinality:
+		return t.Elem.Nilable()
+	}
+	return false
+}
+
+func (t *Type) parseTuple(args []string) error {
+	for i, arg := range args {
+		name, rest, named := cutFieldName(arg)
+		if i > 0 && named != (t.Names != nil) {
+			return fmt.Errorf("mixed named and unnamed Tuple elements")
+		}
+		if named {
+			t.Names = append(t.Names, name)
+			arg = rest
+		}
+
+		field, err := Parse(arg)
+		if err != nil {
+			return err
+		}
+		t.Fields = append(t.Fields, field)
+	}
+	return nil
+}
+
+func (t *Type) parseDecimal(args []string) error {
+	var err error
+	if precision, ok := decimalPrecisions[t.Name]; ok {
+		if len(args) != 1 {
+			return fmt.Errorf("%s takes a scale", t.Name)
+		}
+		t.Precision = precision
+		t.Scale, err = strconv.Atoi(args[0])
+	} else {
+		if len(args) != 2 {
+			return fmt.Errorf("Decimal takes a precision and a scale")
+		}
+		if t.Precision, err = strconv.Atoi(args[0]); err == nil {
+			t.Scale, err = strconv.Atoi(args[1])
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if t.Scale < 0 || t.Precision > 0 && t.Scale > t.Precision {
+		return fmt.Errorf("scale %d out of range", t.Scale)
+	}
+	return nil
+}
+
+func (t *Type) parseDateTime(args []string) error {
+	timezone := ""
+	switch {
+	case t.Kind == KindDateTime && len(args) == 1:
+		timezone = args[0]
+	case t.Kind == KindDateTime64 && len(args) >= 1 && len(args) <= 2:
+		precision, err := strconv.Atoi(args[0])
+		if err != nil || precision < 0 || precision > 9 {
+			return fmt.Errorf("invalid precision %q", args[0])
+		}
+		t.Precision = precision
+		if len(args) == 2 {
+			timezone = args[1]
+		}
+	case len(args) != 0:
+		return fmt.Errorf("too many arguments")
+	case t.Kind == KindDateTime64:
+		t.Precision = 3
+	}
+
+	if timezone == "" {
+		return nil
+	}
+	location, err := time.LoadLocation(strings.Trim(timezone, "'"))
+	if err != nil {
+		return err
+	}
+	t.Location = location
+	return nil
+}
+
+// splitType splits a type into its name and top-level arguments
+func splitType(s string) (string, []string, error) {
+	open := strings.IndexByte(s, '(')
+	if open < 0 {
+		if s == "" || strings.ContainsAny(s, ") ,") {
+			return "", nil, fmt.Errorf("malformed type")
+		}
+		return s, nil, nil
+	}
+	if !strings.HasSuffix(s, ")") {
+		return "", nil, fmt.Errorf("unbalanced parentheses")
+	}
+
+	var args []string
+	depth, start := 0, open+1
+	var quote byte
+	for i := open + 1; i < len(s)-1; i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return "", nil, fmt.Errorf("unbalanced parentheses")
+			}
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 || quote != 0 {
+		return "", nil, fmt.Errorf("unbalanced parentheses")
+	}
+	if last := strings.TrimSpace(s[start : len(s)-1]); last != "" || len(args) > 0 {
+		args = append(args, last)
+	}
+
+	return strings.TrimSpace(s[:open]), args, nil
+}
+
+// cutFieldName splits the name from a named Tuple element such as "id UInt64"
+func cutFieldName(arg string) (string, string, bool) {
+	i := strings.IndexAny(arg, " \t")
+	if i <= 0 {
+		return "", arg, false
+	}
+	name := strings.Trim(arg[:i], "`")
+	for _, c := range name {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return "", arg, false
+		}
+	}
+	return name, strings.TrimSpace(arg[i:]), true
+}
@@ -0,0 +1,248 @@
+package chtypes
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	typ, err := Parse("Map(LowCardinality(String), Array(Nullable(DateTime64(6, 'Europe/Madrid'))))")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if typ.Kind != KindMap || typ.Key.Kind != KindLowCardinality || typ.Key.Elem.Kind != KindString {
+		t.Errorf("unexpected Map key: %+v", typ.Key)
+	}
+	timestamp := typ.Elem.Elem.Elem
+	if timestamp.Kind != KindDateTime64 || timestamp.Precision != 6 || timestamp.Location.String() != "Europe/Madrid" {
+		t.Errorf("unexpected DateTime64: %+v", timestamp)
+	}
+
+	decimal := MustParse("Decimal(18, 4)")
+	if decimal.Precision != 18 || decimal.Scale != 4 {
+		t.Errorf("Decimal(18, 4) = precision %d scale %d", decimal.Precision, decimal.Scale)
+	}
+	if d := MustParse("Decimal64(2)"); d.Precision != 18 || d.Scale != 2 {
+		t.Errorf("Decimal64(2) = precision %d scale %d", d.Precision, d.Scale)
+	}
+
+	tuple := MustParse("Tuple(id UInt64, tags Array(String))")
+	if !reflect.DeepEqual(tuple.Names, []string{"id", "tags"}) || len(tuple.Fields) != 2 {
+		t.Errorf("unexpected named Tuple: %+v", tuple)
+	}
+
+	if simple := MustParse("SimpleAggregateFunction(sum, UInt64)"); simple.Kind != KindUInt64 {
+		t.Errorf("SimpleAggregateFunction kind = %v, want UInt64", simple.Kind)
+	}
+	if unknown := MustParse("AggregateFunction(uniq, String)"); unknown.Kind != KindUnknown {
+		t.Errorf("AggregateFunction kind = %v, want Unknown", unknown.Kind)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, typ := range []string{
+		"",
+		"Nullable(String",
+		"Array(String, String)",
+		"Map(String)",
+		"Decimal(4, 6)",
+		"DateTime('Nowhere/Invalid')",
+		"DateTime64(12)",
+		"Tuple(a String, Int8)",
+	} {
+		if _, err := Parse(typ); err == nil {
+			t.Errorf("Parse(%q): expected an error", typ)
+		}
+	}
+}
+
+func TestGoType(t *testing.T) {
+	tests := map[string]string{
+		"LowCardinality(Nullable(String))": "*string",
+		"Nullable(DateTime64(3))":          "*time.Time",
+		"Array(LowCardinality(String))":    "[]string",
+		"Map(String, UInt64)":              "map[string]uint64",
+		"Map(UInt8, Decimal(18, 4))":       "map[uint8]chtypes.Decimal",
+		"Nullable(UInt256)":                "*big.Int",
+		"IPv6":                             "netip.Addr",
+		"UUID":                             "chtypes.UUID",
+		"Tuple(String, Int8)":              "[]interface{}",
+		"Tuple(a String, b Int8)":          "map[string]interface{}",
+		"AggregateFunction(uniq, String)":  "interface{}",
+	}
+	for typ, expected := range tests {
+		if got := MustParse(typ).GoType(); got != expected {
+			t.Errorf("GoType(%q) = %q, want %q", typ, got, expected)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	uint128, _ := new(big.Int).SetString("340282366920938463463374607431768211455", 10)
+	price, _ := ParseDecimal("12.3400")
+	id, _ := ParseUUID("61f0c404-5cb3-11e7-907b-a6006ad3dba0")
+	hits := uint64(18446744073709551615)
+
+	tests := []struct {
+		typ      string
+		value    interface{}
+		expected interface{}
+	}{
+		{"UInt64", "18446744073709551615", uint64(18446744073709551615)},
+		{"Int32", float64(-42), int32(-42)},
+		{"Float64", "inf", math.Inf(1)},
+		{"UInt128", "340282366920938463463374607431768211455", uint128},
+		{"Decimal(18, 4)", json.Number("12.34"), price},
+		{"UUID", "61f0c404-5cb3-11e7-907b-a6006ad3dba0", id},
+		{"IPv4", "10.0.0.1", netip.MustParseAddr("10.0.0.1")},
+		{"Date", "2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"DateTime64(3, 'Europe/Madrid')", "2024-05-01 12:30:00.125", time.Date(2024, 5, 1, 12, 30, 0, 125e6, madrid)},
+		{"Nullable(UInt64)", "18446744073709551615", &hits},
+		{"Nullable(UInt64)", nil, (*uint64)(nil)},
+		{"LowCardinality(Nullable(String))", nil, (*string)(nil)},
+		{"Array(Nullable(String))", []interface{}{"a", nil}, []*string{ptr("a"), nil}},
+		{"Map(String, UInt8)", map[string]interface{}{"a": float64(1)}, map[string]uint8{"a": 1}},
+		{"Map(UInt16, String)", map[string]interface{}{"7": "x"}, map[uint16]string{7: "x"}},
+		{"Tuple(String, Int8)", []interface{}{"a", float64(1)}, []interface{}{"a", int8(1)}},
+		{"Tuple(id UInt8, name String)", map[string]interface{}{"id": float64(1), "name": "a"}, map[string]interface{}{"id": uint8(1), "name": "a"}},
+		{"AggregateFunction(uniq, String)", "raw", "raw"},
+	}
+
+	for _, test := range tests {
+		decoded, err := Decode(test.typ, test.value)
+		if err != nil {
+			t.Errorf("Decode(%s, %v) error: %v", test.typ, test.value, err)
+			continue
+		}
+		if reflect.TypeOf(decoded) != reflect.TypeOf(test.expected) {
+			t.Errorf("Decode(%s) type = %T, want %T", test.typ, decoded, test.expected)
+			continue
+		}
+
+		switch expected := test.expected.(type) {
+		case time.Time:
+			got := decoded.(time.Time)
+			if !got.Equal(expected) || got.Location().String() != expected.Location().String() {
+				t.Errorf("Decode(%s) = %v, want %v", test.typ, got, expected)
+			}
+		case *big.Int:
+			if decoded.(*big.Int).Cmp(expected) != 0 {
+				t.Errorf("Decode(%s) = %v, want %v", test.typ, decoded, expected)
+			}
+		case Decimal:
+			if got := decoded.(Decimal); got.String() != expected.String() {
+				t.Errorf("Decode(%s) = %v, want %v", test.typ, got, expected)
+			}
+		default:
+			if !reflect.DeepEqual(decoded, test.expected) {
+				t.Errorf("Decode(%s) = %#v, want %#v", test.typ, decoded, test.expected)
+			}
+		}
+	}
+}
+
+func TestDecode_MatchesGoType(t *testing.T) {
+	values := map[string]interface{}{
+		"Nullable(Int128)":                 "1",
+		"LowCardinality(Nullable(String))": "a",
+		"Array(Array(Float32))":            []interface{}{[]interface{}{float64(1)}},
+		"Map(String, Nullable(DateTime))":  map[string]interface{}{"a": "2024-05-01 00:00:00"},
+		"JSON":                             map[string]interface{}{"a": "b"},
+	}
+	for typ, value := range values {
+		parsed := MustParse(typ)
+		decoded, err := parsed.Decode(value)
+		if err != nil {
+			t.Errorf("Decode(%s) error: %v", typ, err)
+			continue
+		}
+		if reflect.TypeOf(decoded) != parsed.ReflectType() {
+			t.Errorf("Decode(%s) type = %T, want %v", typ, decoded, parsed.ReflectType())
+		}
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := map[string]interface{}{
+		"UInt8":               "256",
+		"String":              nil,
+		"UUID":                "not-a-uuid",
+		"Int32":               1.5,
+		"Int64":               float64(42),
+		"UInt64":              float64(1 << 60),
+		"Decimal(18, 4)":      12.34,
+		"Array(String)":       "a",
+		"Tuple(String, Int8)": []interface{}{"a"},
+	}
+	for typ, value := range tests {
+		if _, err := Decode(typ, value); err == nil {
+			t.Errorf("Decode(%s, %v): expected an error", typ, value)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		scale    int
+		expected string
+	}{
+		{"12.345", 2, "12.35"},
+		{"-12.345", 2, "-12.35"},
+		{"0.5", 0, "1"},
+		{"1.5e-3", 4, "0.0015"},
+		{"-0.01", 4, "-0.0100"},
+		{"12", 3, "12.000"},
+	}
+	for _, test := range tests {
+		d, err := ParseDecimal(test.input)
+		if err != nil {
+			t.Errorf("ParseDecimal(%q) error: %v", test.input, err)
+			continue
+		}
+		if got := d.Rescale(test.scale).String(); got != test.expected {
+			t.Errorf("ParseDecimal(%q).Rescale(%d) = %s, want %s", test.input, test.scale, got, test.expected)
+		}
+	}
+
+	var d Decimal
+	if err := json.Unmarshal([]byte(`"123456789012345678901234567890.12"`), &d); err != nil {
+		t.Fatalf("UnmarshalJSON() error: %v", err)
+	}
+	encoded, _ := json.Marshal(d)
+	if string(encoded) != "123456789012345678901234567890.12" {
+		t.Errorf("MarshalJSON() = %s", encoded)
+	}
+
+	if (Decimal{}).String() != "0" {
+		t.Errorf("zero Decimal = %s, want 0", Decimal{})
+	}
+}
+
+func TestUUID(t *testing.T) {
+	u, err := ParseUUID("61F0C404-5CB3-11E7-907B-A6006AD3DBA0")
+	if err != nil {
+		t.Fatalf("ParseUUID() error: %v", err)
+	}
+	if u.String() != "61f0c404-5cb3-11e7-907b-a6006ad3dba0" {
+		t.Errorf("String() = %s", u)
+	}
+	if _, err := ParseUUID("61f0c404-5cb3-11e7-907b-a6006ad3dbaz"); err == nil {
+		t.Error("expected an error for invalid hex")
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,144 @@
+package chtypes
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number, the unscaled integer times 10^-scale.
+// The zero value is 0.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+// NewDecimal returns the decimal unscaled * 10^-scale
+func NewDecimal(unscaled *big.Int, scale int) Decimal {
+	return Decimal{unscaled: new(big.Int).Set(unscaled), scale: scale}
+}
+
+// ParseDecimal parses a decimal such as -12.3400, keeping its digits as the scale.
+// Exponents, as in 1.5e-7, are accepted.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+
+	exponent := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		if exponent, err = strconv.Atoi(s[i+1:]); err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		s = s[:i]
+	}
+
+	digits, scale := s, 0
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		digits = s[:i] + s[i+1:]
+		scale = len(s) - i - 1
+	}
+
+	unscaled, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	d := Decimal{unscaled: unscaled, scale: scale - exponent}
+	if d.scale < 0 {
+		return d.Rescale(0), nil
+	}
+	return d, nil
+}
+
+// Unscaled returns the unscaled integer value
+func (d Decimal) Unscaled() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(d.unscaled)
+}
+
+// Scale returns the number of fractional digits
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Rescale returns d with scale fractional digits, rounding half away from zero
+func (d Decimal) Rescale(scale int) Decimal {
+	unscaled := d.Unscaled()
+	switch {
+	case scale > d.scale:
+		unscaled.Mul(unscaled, pow10(scale-d.scale))
+	case scale < d.scale:
+		divisor := pow10(d.scale - scale)
+		quotient, remainder := new(big.Int).QuoRem(unscaled, divisor, new(big.Int))
+		if remainder.Abs(remainder).Lsh(remainder, 1).Cmp(divisor) >= 0 {
+			if unscaled.Sign() < 0 {
+				quotient.Sub(quotient, big.NewInt(1))
+			} else {
+				quotient.Add(quotient, big.NewInt(1))
+			}
+		}
+		unscaled = quotient
+	}
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+// Rat returns d as an exact rational number
+func (d Decimal) Rat() *big.Rat {
+	r := new(big.Rat).SetInt(d.Unscaled())
+	if d.scale > 0 {
+		r.Quo(r, new(big.Rat).SetInt(pow10(d.scale)))
+	}
+	return r
+}
+
+// Float64 returns the nearest float64 to d
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// Cmp compares d and other, returning -1, 0 or +1
+func (d Decimal) Cmp(other Decimal) int {
+	return d.Rat().Cmp(other.Rat())
+}
+
+// String returns d with exactly Scale fractional digits, such as -12.3400
+func (d Decimal) String() string {
+	s := d.Unscaled().String()
+	if d.scale <= 0 {
+		return s
+	}
+
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	if len(s) <= d.scale {
+		s = strings.Repeat("0", d.scale-len(s)+1) + s
+	}
+	s = s[:len(s)-d.scale] + "." + s[len(s)-d.scale:]
+	if negative {
+		s = "-" + s
+	}
+	return s
+}
+
+// MarshalJSON encodes d as a JSON number, without losing precision
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON decodes a JSON number or quoted number
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	parsed, err := ParseDecimal(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,386 @@
+package chtypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layouts of dates and times in ClickHouse JSON output
+const (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02 15:04:05.999999999"
+)
+
+var (
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+	objectType    = reflect.TypeOf(map[string]interface{}(nil))
+	tupleType     = reflect.TypeOf([]interface{}(nil))
+	bigIntType    = reflect.TypeOf((*big.Int)(nil))
+	timeType      = reflect.TypeOf(time.Time{})
+	addrType      = reflect.TypeOf(netip.Addr{})
+)
+
+// ReflectType returns the Go type of values decoded with Decode
+func (t *Type) ReflectType() reflect.Type {
+	switch t.Kind {
+	case KindBool:
+		return reflect.TypeOf(false)
+	case KindInt8:
+		return reflect.TypeOf(int8(0))
+	case KindInt16:
+		return reflect.TypeOf(int16(0))
+	case KindInt32:
+		return reflect.TypeOf(int32(0))
+	case KindInt64:
+		return reflect.TypeOf(int64(0))
+	case KindUInt8:
+		return reflect.TypeOf(uint8(0))
+	case KindUInt16:
+		return reflect.TypeOf(uint16(0))
+	case KindUInt32:
+		return reflect.TypeOf(uint32(0))
+	case KindUInt64:
+		return reflect.TypeOf(uint64(0))
+	case KindInt128, KindInt256, KindUInt128, KindUInt256:
+		return bigIntType
+	case KindFloat32:
+		return reflect.TypeOf(float32(0))
+	case KindFloat64:
+		return reflect.TypeOf(float64(0))
+	case KindDecimal:
+		return reflect.TypeOf(Decimal{})
+	case KindString, KindFixedString, KindEnum:
+		return reflect.TypeOf("")
+	case KindUUID:
+		return reflect.TypeOf(UUID{})
+	case KindIPv4, KindIPv6:
+		return addrType
+	case KindDate, KindDate32, KindDateTime, KindDateTime64:
+		return timeType
+	case KindArray:
+		return reflect.SliceOf(t.Elem.ReflectType())
+	case KindMap:
+		key := t.Key.ReflectType()
+		if !key.Comparable() || key.Kind() == reflect.Pointer {
+			key = reflect.TypeOf("")
+		}
+		return reflect.MapOf(key, t.Elem.ReflectType())
+	case KindTuple:
+		if t.Names != nil {
+			return objectType
+		}
+		return tupleType
+	case KindNullable:
+		if t.Elem.Nilable() {
+			return t.Elem.ReflectType()
+		}
+		return reflect.PointerTo(t.Elem.ReflectType())
+	case KindLowCardinality:
+		return t.Elem.ReflectType()
+	case KindJSON:
+		return objectType
+	}
+	return interfaceType
+}
+
+// Decode converts a value decoded from JSON, such as a float64, string, or
+// json.Number, into a value of ReflectType. 64-bit and larger integers may be
+// quoted strings, as ClickHouse writes them by default. Those integers and
+// decimals must not be float64, which cannot hold them exactly, so decode the
+// JSON with json.Decoder.UseNumber.
+func (t *Type) Decode(v interface{}) (interface{}, error) {
+	value, err := t.decode(v)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode %s as %s: %w", describe(v), t, err)
+	}
+	return value.Interface(), nil
+}
+
+// Decode parses typ and decodes v, as Type.Decode
+func Decode(typ string, v interface{}) (interface{}, error) {
+	t, err := Parse(typ)
+	if err != nil {
+		return nil, err
+	}
+	return t.Decode(v)
+}
+
+func (t *Type) decode(v interface{}) (reflect.Value, error) {
+	if t.Kind == KindLowCardinality {
+		return t.Elem.decode(v)
+	}
+
+	typ := t.ReflectType()
+	if v == nil {
+		if t.Kind == KindNullable || typ == interfaceType {
+			return reflect.Zero(typ), nil
+		}
+		return reflect.Value{}, fmt.Errorf("unexpected null")
+	}
+
+	switch t.Kind {
+	case KindBool:
+		switch b := v.(type) {
+		case bool:
+			return reflect.ValueOf(b), nil
+		case float64:
+			return reflect.ValueOf(b != 0), nil
+		case string:
+			parsed, err := strconv.ParseBool(b)
+			return reflect.ValueOf(parsed), err
+		}
+
+	case KindInt8, KindInt16, KindInt32, KindInt64:
+		s, err := numberString(v, typ.Bits() < 64)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		n, err := strconv.ParseInt(s, 10, typ.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(n).Convert(typ), nil
+
+	case KindUInt8, KindUInt16, KindUInt32, KindUInt64:
+		s, err := numberString(v, typ.Bits() < 64)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		n, err := strconv.ParseUint(s, 10, typ.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(n).Convert(typ), nil
+
+	case KindInt128, KindInt256, KindUInt128, KindUInt256:
+		s, err := numberString(v, false)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		n, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("invalid integer")
+		}
+		return reflect.ValueOf(n), nil
+
+	case KindFloat32, KindFloat64:
+		switch f := v.(type) {
+		case float64:
+			return reflect.ValueOf(f).Convert(typ), nil
+		case json.Number:
+			parsed, err := strconv.ParseFloat(string(f), typ.Bits())
+			return reflect.ValueOf(parsed).Convert(typ), err
+		case string:
+			// ClickHouse writes inf and nan as strings
+			parsed, err := strconv.ParseFloat(f, typ.Bits())
+			return reflect.ValueOf(parsed).Convert(typ), err
+		}
+
+	case KindDecimal:
+		s, err := numberString(v, false)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		d, err := ParseDecimal(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(d.Rescale(t.Scale)), nil
+
+	case KindString, KindFixedString, KindEnum:
+		if s, ok := v.(string); ok {
+			return reflect.ValueOf(s), nil
+		}
+
+	case KindUUID:
+		if s, ok := v.(string); ok {
+			u, err := ParseUUID(s)
+			return reflect.ValueOf(u), err
+		}
+
+	case KindIPv4, KindIPv6:
+		if s, ok := v.(string); ok {
+			addr, err := netip.ParseAddr(s)
+			return reflect.ValueOf(addr), err
+		}
+
+	case KindDate, KindDate32, KindDateTime, KindDateTime64:
+		parsed, err := t.decodeTime(v)
+		return reflect.ValueOf(parsed), err
+
+	case KindArray:
+		items, ok := v.([]interface{})
+		if !ok {
+			break
+		}
+		slice := reflect.MakeSlice(typ, len(items), len(items))
+		for i, item := range items {
+			elem, err := t.Elem.decode(item)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d: %w", i, err)
+			}
+			slice.Index(i).Set(elem)
+		}
+		return slice, nil
+
+	case KindMap:
+		entries, ok := v.(map[string]interface{})
+		if !ok {
+			break
+		}
+		m := reflect.MakeMapWithSize(typ, len(entries))
+		for k, item := range entries {
+			key := reflect.ValueOf(k)
+			if typ.Key() != key.Type() {
+				var err error
+				if key, err = t.Key.decode(k); err != nil {
+					return reflect.Value{}, fmt.Errorf("key %q: %w", k, err)
+				}
+			}
+			elem, err := t.Elem.decode(item)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("key %q: %w", k, err)
+			}
+			m.SetMapIndex(key, elem)
+		}
+		return m, nil
+
+	case KindTuple:
+		return t.decodeTuple(v)
+
+	case KindNullable:
+		elem, err := t.Elem.decode(v)
+		if err != nil || t.Elem.Nilable() {
+			return elem, err
+		}
+		ptr := reflect.New(elem.Type())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+
+	case KindJSON:
+		if object, ok := v.(map[string]interface{}); ok {
+			return reflect.ValueOf(object), nil
+		}
+		if s, ok := v.(string); ok {
+			var object map[string]interface{}
+			err := json.Unmarshal([]byte(s), &object)
+			return reflect.ValueOf(object), err
+		}
+
+	default:
+		return reflect.ValueOf(&v).Elem(), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("unexpected %T", v)
+}
+
+func (t *Type) decodeTuple(v interface{}) (reflect.Value, error) {
+	var items []interface{}
+	switch tuple := v.(type) {
+	case []interface{}:
+		items = tuple
+	case map[string]interface{}:
+		if t.Names == nil {
+			return reflect.Value{}, fmt.Errorf("unexpected object for an unnamed Tuple")
+		}
+		items = make([]interface{}, len(t.Names))
+		for i, name := range t.Names {
+			items[i] = tuple[name]
+		}
+	default:
+		return reflect.Value{}, fmt.Errorf("unexpected %T", v)
+	}
+
+	if len(items) != len(t.Fields) {
+		return reflect.Value{}, fmt.Errorf("got %d elements, want %d", len(items), len(t.Fields))
+	}
+
+	decoded := make([]interface{}, len(items))
+	for i, item := range items {
+		elem, err := t.Fields[i].decode(item)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("element %d: %w", i, err)
+		}
+		decoded[i] = elem.Interface()
+	}
+
+	if t.Names == nil {
+		return reflect.ValueOf(decoded), nil
+	}
+
+	object := make(map[string]interface{}, len(decoded))
+	for i, name := range t.Names {
+		object[name] = decoded[i]
+	}
+	return reflect.ValueOf(object), nil
+}
+
+func (t *Type) decodeTime(v interface{}) (time.Time, error) {
+	location := t.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	switch value := v.(type) {
+	case string:
+		layout := dateTimeLayout
+		if t.Kind == KindDate || t.Kind == KindDate32 {
+			layout = dateLayout
+		}
+		parsed, err := time.ParseInLocation(layout, value, location)
+		if err != nil {
+			// Inputs such as events may use RFC 3339
+			if rfc3339, rfcErr := time.Parse(time.RFC3339Nano, value); rfcErr == nil {
+				return rfc3339.In(location), nil
+			}
+		}
+		return parsed, err
+	case float64:
+		// Unix timestamps, with date_time_output_format=unix_timestamp
+		seconds, fraction := math.Modf(value)
+		return time.Unix(int64(seconds), int64(fraction*1e9)).In(location), nil
+	case json.Number:
+		f, err := value.Float64()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t.decodeTime(f)
+	}
+
+	return time.Time{}, fmt.Errorf("unexpected %T", v)
+}
+
+// numberString returns the text of a number decoded from JSON. Unless floatExact
+// is set, meaning every value of the target type fits a float64 exactly, a float64
+// is rejected since it only holds integers up to 2^53 and few decimal fractions.
+func numberString(v interface{}, floatExact bool) (string, error) {
+	switch n := v.(type) {
+	case string:
+		return strings.TrimSpace(n), nil
+	case json.Number:
+		return string(n), nil
+	case float64:
+		if !floatExact {
+			return "", fmt.Errorf("float64 may have lost precision; decode the JSON with UseNumber")
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("unexpected %T", v)
+}
+
+func describe(v interface{}) string {
+	if s, ok := v.(string); ok {
+		if len(s) > 40 {
+			s = s[:40] + "..."
+		}
+		return strconv.Quote(s)
+	}
+	return fmt.Sprintf("%T", v)
+}
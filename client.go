@@ -1,6 +1,7 @@
 package tinybird
 
 import (
+	"fmt"
 	"os"
 	"time"
 
@@ -58,6 +59,136 @@ func RetryDelay(delay time.Duration) Option {
 	}
 }
 
+// MaxIdleConnsPerHost sets the maximum number of idle connections kept per host in ClientOptions.
+func MaxIdleConnsPerHost(n int) Option {
+	return func(co *ClientOptions) {
+		co.MaxIdleConnsPerHost = n
+	}
+}
+
+// MaxConnsPerHost sets the maximum number of connections per host in ClientOptions.
+func MaxConnsPerHost(n int) Option {
+	return func(co *ClientOptions) {
+		co.MaxConnsPerHost = n
+	}
+}
+
+// IdleConnTimeout sets how long idle connections are kept in the pool in ClientOptions.
+func IdleConnTimeout(timeout time.Duration) Option {
+	return func(co *ClientOptions) {
+		co.IdleConnTimeout = timeout
+	}
+}
+
+// HTTP2 enables or disables HTTP/2 negotiation in ClientOptions.
+func HTTP2(enabled bool) Option {
+	return func(co *ClientOptions) {
+		co.DisableHTTP2 = !enabled
+	}
+}
+
+// TCPKeepAlive sets the TCP keepalive interval in ClientOptions.
+func TCPKeepAlive(interval time.Duration) Option {
+	return func(co *ClientOptions) {
+		co.TCPKeepAlive = interval
+	}
+}
+
+// TLSSessionCacheSize sets the number of TLS sessions cached for resumption in ClientOptions.
+func TLSSessionCacheSize(size int) Option {
+	return func(co *ClientOptions) {
+		co.TLSSessionCacheSize = size
+	}
+}
+
+// HighThroughput applies connection pool settings suited to sustained, highly
+// concurrent ingestion against a single Tinybird host.
+func HighThroughput() Option {
+	return func(co *ClientOptions) {
+		co.MaxIdleConnsPerHost = 256
+		co.MaxConnsPerHost = 0
+		co.IdleConnTimeout = 120 * time.Second
+		co.DisableHTTP2 = false
+		co.TCPKeepAlive = 30 * time.Second
+		co.TLSSessionCacheSize = 256
+	}
+}
+
+// Spool enables the durable on-disk spool for events in ClientOptions.
+//
+// dir:      Directory holding the spool segment files.
+//
+// maxBytes: Maximum disk usage of the spool, 0 means unlimited.
+func Spool(dir string, maxBytes int64) Option {
+	return func(co *ClientOptions) {
+		co.SpoolDir = dir
+		co.SpoolMaxBytes = maxBytes
+	}
+}
+
+// SpoolRetryInterval sets the delay between spool delivery attempts in ClientOptions.
+func SpoolRetryInterval(interval time.Duration) Option {
+	return func(co *ClientOptions) {
+		co.SpoolRetryInterval = interval
+	}
+}
+
+// SpoolErrorHandler sets the callback for spool failures and dropped payloads in ClientOptions.
+func SpoolErrorHandler(handler func(err error)) Option {
+	return func(co *ClientOptions) {
+		co.SpoolErrorHandler = handler
+	}
+}
+
+// CircuitBreaker enables circuit breaking around API calls in ClientOptions.
+func CircuitBreaker(options CircuitBreakerOptions) Option {
+	return func(co *ClientOptions) {
+		co.CircuitBreaker = &options
+	}
+}
+
+// RateLimiting enables client-side rate limiting of API calls in ClientOptions.
+func RateLimiting(options RateLimitOptions) Option {
+	return func(co *ClientOptions) {
+		co.RateLimit = &options
+	}
+}
+
+// EndpointCache enables caching of CallEndpoint responses in ClientOptions.
+func EndpointCache(options CacheOptions) Option {
+	return func(co *ClientOptions) {
+		co.Cache = &options
+	}
+}
+
+// CoalesceRequests sets whether concurrent identical CallEndpoint calls share one request in ClientOptions.
+func CoalesceRequests(enabled bool) Option {
+	return func(co *ClientOptions) {
+		co.CoalesceRequests = enabled
+	}
+}
+
+// Hedging enables hedged requests for CallEndpoint and Query in ClientOptions.
+func Hedging(options HedgingOptions) Option {
+	return func(co *ClientOptions) {
+		co.Hedging = &options
+	}
+}
+
+// DriftDetection enables background schema drift checks of SendEvents payloads in ClientOptions.
+func DriftDetection(options SchemaDriftOptions) Option {
+	return func(co *ClientOptions) {
+		co.DriftDetection = &options
+	}
+}
+
+// EndpointPostThreshold sets the query string length above which CallEndpoint uses POST in ClientOptions.
+func EndpointPostThreshold(length int) Option {
+	return func(co *ClientOptions) {
+		co.EndpointPostThreshold = length
+	}
+}
+
 // NewClientOptions creates a new ClientOptions instance with the provided options.
 func NewClientOptions(options ...Option) *ClientOptions {
 	co := &ClientOptions{}
@@ -122,11 +253,83 @@ func NewClient(options *ClientOptions, http httpclient.Client) Client {
 			MaxRetries: options.MaxRetries,
 			UserAgent:  "com.nollywood/tinybirdclient/" + VERSION,
 			Token:      options.Token,
+			Transport: httpclient.TransportConfig{
+				MaxIdleConnsPerHost: options.MaxIdleConnsPerHost,
+				MaxConnsPerHost:     options.MaxConnsPerHost,
+				IdleConnTimeout:     options.IdleConnTimeout,
+				DisableHTTP2:        options.DisableHTTP2,
+				KeepAlive:           options.TCPKeepAlive,
+				TLSSessionCacheSize: options.TLSSessionCacheSize,
+			},
+			CircuitBreaker: breakerConfig(options.CircuitBreaker),
+			RateLimit:      rateLimitConfig(options.RateLimit),
 		})
 	}
 
-	return &ClientImpl{
+	client := &ClientImpl{
 		httpClient: http,
 		options:    options,
 	}
+
+	if options.Cache != nil {
+		client.cache = newEndpointCache(options.Cache)
+	}
+
+	if options.CoalesceRequests {
+		client.calls = newCallGroup()
+	}
+
+	if options.Hedging != nil {
+		client.hedger = newHedger(options.Hedging)
+	}
+
+	if options.DriftDetection != nil {
+		client.drift = newDriftMonitor(client, options.DriftDetection)
+	}
+
+	if options.SpoolDir != "" {
+		eventSpool, err := newEventSpool(client, options)
+		if err != nil {
+			// Spooling is best effort: the client keeps working without it
+			if options.SpoolErrorHandler != nil {
+				options.SpoolErrorHandler(fmt.Errorf("failed to open spool: %w", err))
+			}
+		} else {
+			client.spool = eventSpool
+		}
+	}
+
+	return client
+}
+
+// breakerConfig converts CircuitBreakerOptions to the internal HTTP client configuration
+func breakerConfig(options *CircuitBreakerOptions) *httpclient.BreakerConfig {
+	if options == nil {
+		return nil
+	}
+
+	return &httpclient.BreakerConfig{
+		FailureRatio:     options.FailureRatio,
+		MinRequests:      options.MinRequests,
+		Window:           options.Window,
+		OpenTimeout:      options.OpenTimeout,
+		HalfOpenRequests: options.HalfOpenRequests,
+		PerResource:      options.PerResource,
+		OnStateChange:    options.OnStateChange,
+	}
+}
+
+// rateLimitConfig converts RateLimitOptions to the internal HTTP client configuration
+func rateLimitConfig(options *RateLimitOptions) *httpclient.RateLimitConfig {
+	if options == nil {
+		return nil
+	}
+
+	return &httpclient.RateLimitConfig{
+		Global:      options.Global,
+		Pipes:       options.Pipes,
+		Datasources: options.Datasources,
+		FailFast:    options.FailFast,
+		AutoTune:    options.AutoTune,
+	}
 }
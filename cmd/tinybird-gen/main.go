@@ -0,0 +1,150 @@
+// Command tinybird-gen generates typed Go clients from the .datasource and
+// .pipe files of a Tinybird project.
+//
+// Usage:
+//
+//	tinybird-gen [-out tinybird_gen.go] [-package name] [-meta meta.json] [dir]
+//
+// It reads every datafile under dir, the current directory by default, and
+// writes a single Go file. Directories starting with a dot are skipped.
+//
+// The -meta file maps pipe names to the meta of their responses, as returned
+// by the endpoint, and overrides the result columns inferred from the SQL:
+//
+//	{"top_pages": [{"name": "path", "type": "String"}, {"name": "hits", "type": "UInt64"}]}
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/NOLLYWOOD-COM/tinybird/internal/codegen"
+	"github.com/NOLLYWOOD-COM/tinybird/internal/datafile"
+)
+
+func main() {
+	out := flag.String("out", "tinybird_gen.go", "Output file, - for stdout")
+	pkg := flag.String("package", "", "Package name, defaults to the name of the output directory")
+	meta := flag.String("meta", "", "JSON file with the result columns of endpoints, by pipe name")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: tinybird-gen [flags] [dir]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	if err := run(dir, *out, *pkg, *meta); err != nil {
+		fmt.Fprintf(os.Stderr, "tinybird-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, out string, pkg string, metaPath string) error {
+	project, err := readProject(dir)
+	if err != nil {
+		return err
+	}
+
+	config := codegen.Config{Package: pkg}
+	if config.Package == "" {
+		config.Package = packageName(out)
+	}
+
+	if metaPath != "" {
+		data, err := os.ReadFile(metaPath)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &config.Meta); err != nil {
+			return fmt.Errorf("invalid meta file %s: %w", metaPath, err)
+		}
+	}
+
+	source, err := codegen.Generate(project, config)
+	if err != nil {
+		return err
+	}
+
+	if out == "-" {
+		_, err = os.Stdout.Write(source)
+		return err
+	}
+	return os.WriteFile(out, source, 0o644)
+}
+
+// readProject parses every datafile under dir
+func readProject(dir string) (*codegen.Project, error) {
+	project := &codegen.Project{}
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch filepath.Ext(path) {
+		case ".datasource":
+			datasource, err := datafile.ReadDatasource(path)
+			if err != nil {
+				return err
+			}
+			project.Datasources = append(project.Datasources, datasource)
+		case ".pipe":
+			pipe, err := datafile.ReadPipe(path)
+			if err != nil {
+				return err
+			}
+			project.Pipes = append(project.Pipes, pipe)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(project.Datasources) == 0 && len(project.Pipes) == 0 {
+		return nil, fmt.Errorf("no .datasource or .pipe files in %s", dir)
+	}
+
+	return project, nil
+}
+
+// packageName derives a package name from the directory of the output file
+func packageName(out string) string {
+	dir := "."
+	if out != "-" {
+		dir = filepath.Dir(out)
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		if r >= 'A' && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return -1
+	}, filepath.Base(dir))
+
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return "tinybird"
+	}
+	return name
+}
@@ -0,0 +1,139 @@
+package tinybird
+
+import (
+	"context"
+	"sync"
+)
+
+// callGroup deduplicates concurrent identical calls so only one request is in flight per key
+type callGroup struct {
+	mu    sync.Mutex
+	calls map[string]*inflightCall
+}
+
+type inflightCall struct {
+	done     chan struct{}
+	response *EndpointResponse
+	err      error
+
+	// Callers still waiting for the result; the request is cancelled when all have given up
+	waiters int
+	cancel  context.CancelFunc
+}
+
+func newCallGroup() *callGroup {
+	return &callGroup{calls: map[string]*inflightCall{}}
+}
+
+// do runs fetch for key unless an identical call is already in flight, in which case
+// it waits for that call. Every caller receives its own copy of the response.
+//
+// The shared request is not tied to any single caller's context: a caller that
+// gives up stops waiting, and the request is only cancelled once every caller has.
+func (g *callGroup) do(ctx context.Context, key string, fetch func(context.Context) (*EndpointResponse, error)) (*EndpointResponse, error) {
+	g.mu.Lock()
+	call, ok := g.calls[key]
+	if ok {
+		call.waiters++
+	} else {
+		fetchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &inflightCall{
+			done:    make(chan struct{}),
+			waiters: 1,
+			cancel:  cancel,
+		}
+		g.calls[key] = call
+
+		go g.run(fetchCtx, key, call, fetch)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		if call.err != nil {
+			return nil, call.err
+		}
+		return copyEndpointResponse(call.response), nil
+	case <-ctx.Done():
+		g.leave(key, call)
+		return nil, ctx.Err()
+	}
+}
+
+func (g *callGroup) run(ctx context.Context, key string, call *inflightCall, fetch func(context.Context) (*EndpointResponse, error)) {
+	call.response, call.err = fetch(ctx)
+	call.cancel()
+
+	g.mu.Lock()
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+
+	close(call.done)
+}
+
+// leave drops a waiter, cancelling the request when nobody is waiting for it anymore
+func (g *callGroup) leave(key string, call *inflightCall) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+
+	call.cancel()
+	// Later callers must not join a request that is being cancelled
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+}
+
+// copyEndpointResponse deep copies a response so callers sharing it cannot affect each other
+func copyEndpointResponse(response *EndpointResponse) *EndpointResponse {
+	if response == nil {
+		return nil
+	}
+
+	copied := *response
+
+	if response.Meta != nil {
+		copied.Meta = append([]FieldMeta(nil), response.Meta...)
+	}
+
+	if response.Data != nil {
+		copied.Data = make([]map[string]interface{}, len(response.Data))
+		for i, row := range response.Data {
+			copied.Data[i] = copyJSONValue(row).(map[string]interface{})
+		}
+	}
+
+	return &copied
+}
+
+// copyJSONValue deep copies a value decoded by encoding/json
+func copyJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		copied := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			copied[key] = copyJSONValue(elem)
+		}
+		return copied
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		copied := make([]interface{}, len(v))
+		for i, elem := range v {
+			copied[i] = copyJSONValue(elem)
+		}
+		return copied
+	default:
+		return v
+	}
+}
@@ -0,0 +1,147 @@
+package tinybird
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCallEndpoint_CoalescesConcurrentCalls(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+	client.calls = newCallGroup()
+
+	release := make(chan struct{})
+	mockClient.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		<-release
+		args.Get(3).(*EndpointResponse).Data = []map[string]interface{}{
+			{"page": "/", "tags": []interface{}{"a"}},
+		}
+	}).Once()
+
+	const callers = 10
+	responses := make([]*EndpointResponse, callers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			response, err := client.CallEndpoint(context.Background(), "top_pages", map[string]string{"limit": "1"})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			responses[i] = response
+		}(i)
+	}
+
+	// Let every caller join the in-flight request before it completes
+	waitForWaiters(t, client.calls, callers)
+	close(release)
+	wg.Wait()
+
+	mockClient.AssertExpectations(t)
+
+	// Each caller owns its response
+	responses[0].Data[0]["tags"].([]interface{})[0] = "changed"
+	if got := responses[1].Data[0]["tags"].([]interface{})[0]; got != "a" {
+		t.Errorf("responses share data: got %v", got)
+	}
+}
+
+func TestCallGroup_CancelledWaiterDoesNotCancelOthers(t *testing.T) {
+	group := newCallGroup()
+
+	release := make(chan struct{})
+	var fetchErr error
+	fetch := func(ctx context.Context) (*EndpointResponse, error) {
+		select {
+		case <-release:
+			return &EndpointResponse{Rows: 1}, nil
+		case <-ctx.Done():
+			fetchErr = ctx.Err()
+			return nil, ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := group.do(ctx, "key", fetch)
+		firstErr <- err
+	}()
+	waitForWaiters(t, group, 1)
+
+	second := make(chan *EndpointResponse, 1)
+	go func() {
+		response, _ := group.do(context.Background(), "key", fetch)
+		second <- response
+	}()
+	waitForWaiters(t, group, 2)
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first caller error = %v, want context.Canceled", err)
+	}
+
+	close(release)
+	if response := <-second; response == nil || response.Rows != 1 {
+		t.Errorf("second caller response = %+v, want the shared response", response)
+	}
+	if fetchErr != nil {
+		t.Errorf("shared request was cancelled: %v", fetchErr)
+	}
+}
+
+func TestCallGroup_LastWaiterCancelsRequest(t *testing.T) {
+	group := newCallGroup()
+
+	cancelled := make(chan struct{})
+	fetch := func(ctx context.Context) (*EndpointResponse, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		group.do(ctx, "key", fetch)
+		close(done)
+	}()
+	waitForWaiters(t, group, 1)
+
+	cancel()
+	<-done
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("request was not cancelled after every caller left")
+	}
+}
+
+// waitForWaiters blocks until the in-flight call for any key has n waiters
+func waitForWaiters(t *testing.T, group *callGroup, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		group.mu.Lock()
+		for _, call := range group.calls {
+			if call.waiters == n {
+				group.mu.Unlock()
+				return
+			}
+		}
+		group.mu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("timed out waiting for %d waiters", n)
+}
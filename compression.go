@@ -0,0 +1,141 @@
+package tinybird
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// encoder is the common interface of the pooled gzip and zstd writers
+type encoder interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+type encoderKey struct {
+	encoding string
+	level    int
+}
+
+var (
+	encoderPoolsMu sync.Mutex
+	encoderPools   = map[encoderKey]*sync.Pool{}
+)
+
+// acquireEncoder returns a pooled encoder for the given encoding and level that
+// writes to w, together with a function that returns it to the pool once closed.
+//
+// A level of 0 selects the encoder's default compression level.
+func acquireEncoder(encoding string, level int, w io.Writer) (encoder, func(), error) {
+	key := encoderKey{encoding: encoding, level: level}
+
+	encoderPoolsMu.Lock()
+	pool, ok := encoderPools[key]
+	encoderPoolsMu.Unlock()
+
+	if ok {
+		if enc, ok := pool.Get().(encoder); ok {
+			enc.Reset(w)
+			return enc, func() { pool.Put(enc) }, nil
+		}
+	}
+
+	enc, err := newEncoder(encoding, level, w)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !ok {
+		encoderPoolsMu.Lock()
+		pool, ok = encoderPools[key]
+		if !ok {
+			pool = &sync.Pool{}
+			encoderPools[key] = pool
+		}
+		encoderPoolsMu.Unlock()
+	}
+
+	return enc, func() { pool.Put(enc) }, nil
+}
+
+func newEncoder(encoding string, level int, w io.Writer) (encoder, error) {
+	switch encoding {
+	case "gzip":
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		gzWriter, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip compression level %d: %w", level, err)
+		}
+		return gzWriter, nil
+	case "zstd":
+		zstdLevel := zstd.SpeedDefault
+		if level != 0 {
+			zstdLevel = zstd.EncoderLevelFromZstd(level)
+		}
+		zstdWriter, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstdLevel))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
+		}
+		return zstdWriter, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression encoding: %s", encoding)
+	}
+}
+
+// compressBytes compresses data in memory using a pooled encoder
+func compressBytes(data []byte, encoding string, level int) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc, release, err := acquireEncoder(encoding, level, &buf)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if _, err := enc.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress data: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close %s writer: %w", encoding, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// compressStream compresses src into the write side of an io.Pipe from a
+// background goroutine and returns the read side. The returned channel is
+// closed once the goroutine has finished with src.
+func compressStream(src io.Reader, encoding string, level int) (io.ReadCloser, <-chan struct{}, error) {
+	pr, pw := io.Pipe()
+
+	enc, release, err := acquireEncoder(encoding, level, pw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		_, err := io.Copy(enc, src)
+		if closeErr := enc.Close(); err == nil {
+			err = closeErr
+		}
+		release()
+
+		if err != nil {
+			pw.CloseWithError(fmt.Errorf("failed to compress data: %w", err))
+			return
+		}
+		pw.Close()
+	}()
+
+	return pr, done, nil
+}
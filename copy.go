@@ -0,0 +1,148 @@
+package tinybird
+
+import (
+	"context"
+	"net/url"
+)
+
+// Copy modes for RunCopyPipe
+const (
+	CopyModeAppend  = "append"
+	CopyModeReplace = "replace"
+)
+
+// Copy schedule statuses reported in CopySchedule.Status
+const (
+	CopyScheduleRunning  = "running"
+	CopyScheduleShutdown = "shutdown"
+)
+
+// jobResponse is returned by endpoints that start a job. Some nest the job under
+// "job" while others return it at the top level.
+type jobResponse struct {
+	Job
+	Nested *Job `json:"job"`
+}
+
+func (r *jobResponse) job() *Job {
+	job := r.Job
+	if r.Nested != nil {
+		job = *r.Nested
+	}
+	if job.ID == "" {
+		job.ID = job.JobID
+	}
+	return &job
+}
+
+func (c *ClientImpl) GetCopyPipe(ctx context.Context, pipeName string) (*CopyPipe, error) {
+	var pipe CopyPipe
+
+	err := c.httpClient.Get(ctx, c.apiURL("pipes/"+url.PathEscape(pipeName)), nil, &pipe)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pipe, nil
+}
+
+func (c *ClientImpl) RunCopyPipe(ctx context.Context, pipeName string, options *CopyRunOptions) (*Job, error) {
+	if options == nil {
+		options = &CopyRunOptions{}
+	}
+
+	params := make(map[string]string, len(options.Params)+1)
+	for k, v := range options.Params {
+		params[k] = v
+	}
+	if options.Mode != "" {
+		params["_mode"] = options.Mode
+	}
+
+	var response jobResponse
+
+	reqUrl := withQueryParams(c.apiURL("pipes/"+url.PathEscape(pipeName)+"/copy"), params)
+	if err := c.httpClient.PostRaw(ctx, reqUrl, nil, "", "", &response); err != nil {
+		return nil, err
+	}
+
+	job := response.job()
+	if !options.Wait {
+		return job, nil
+	}
+
+	return c.WaitForJob(ctx, job.ID, options.PollInterval)
+}
+
+func (c *ClientImpl) PauseCopyPipe(ctx context.Context, pipeName string) (*CopyPipe, error) {
+	return c.copyScheduleAction(ctx, pipeName, "pause")
+}
+
+func (c *ClientImpl) ResumeCopyPipe(ctx context.Context, pipeName string) (*CopyPipe, error) {
+	return c.copyScheduleAction(ctx, pipeName, "resume")
+}
+
+func (c *ClientImpl) copyScheduleAction(ctx context.Context, pipeName string, action string) (*CopyPipe, error) {
+	var pipe CopyPipe
+
+	reqUrl := c.apiURL("pipes/" + url.PathEscape(pipeName) + "/copy/" + action)
+	if err := c.httpClient.PostRaw(ctx, reqUrl, nil, "", "", &pipe); err != nil {
+		return nil, err
+	}
+
+	return &pipe, nil
+}
+
+func (c *ClientImpl) ListCopyRuns(ctx context.Context, pipeName string, limit int) ([]Job, error) {
+	return listJobs[Job](ctx, c, "copy", pipeName, limit)
+}
+
+// jobRecord is a job as listed by the jobs endpoint, either a Job or a type embedding it
+type jobRecord[T any] interface {
+	*T
+	base() *Job
+}
+
+func (j *Job) base() *Job {
+	return j
+}
+
+// listJobs returns the most recent jobs of a kind started by a pipe
+func listJobs[T any, P jobRecord[T]](ctx context.Context, c *ClientImpl, kind string, pipeName string, limit int) ([]T, error) {
+	var response struct {
+		Jobs []T `json:"jobs"`
+	}
+
+	params := map[string]string{
+		"kind":      kind,
+		"pipe_name": pipeName,
+	}
+	if err := c.httpClient.Get(ctx, c.apiURL("jobs"), params, &response); err != nil {
+		return nil, err
+	}
+
+	jobs := response.Jobs
+	if limit > 0 && len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	for i := range jobs {
+		if job := P(&jobs[i]).base(); job.ID == "" {
+			job.ID = job.JobID
+		}
+	}
+
+	return jobs, nil
+}
+
+// withQueryParams appends params to the query string of urlStr
+func withQueryParams(urlStr string, params map[string]string) string {
+	if len(params) == 0 {
+		return urlStr
+	}
+
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	return urlStr + "?" + values.Encode()
+}
@@ -0,0 +1,96 @@
+package tinybird
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRunCopyPipe_WaitsForJob(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockClient.On("PostRaw",
+		mock.Anything,
+		"https://api.tinybird.co/v0/pipes/daily_snapshot/copy?_mode=replace&day=2024-05-01",
+		[]byte(nil),
+		"",
+		"",
+		mock.AnythingOfType("*tinybird.jobResponse"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(5).(*jobResponse).Nested = &Job{ID: "job-1", Kind: "copy", Status: JobStatusWaiting}
+	})
+
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/jobs/job-1",
+		mock.Anything,
+		mock.AnythingOfType("*tinybird.Job"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(3).(*Job) = Job{ID: "job-1", Kind: "copy", Status: JobStatusDone}
+	})
+
+	job, err := client.RunCopyPipe(context.Background(), "daily_snapshot", &CopyRunOptions{
+		Params: map[string]string{"day": "2024-05-01"},
+		Mode:   CopyModeReplace,
+		Wait:   true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.Status != JobStatusDone {
+		t.Errorf("Status = %s, want done", job.Status)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestPauseCopyPipe(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockClient.On("PostRaw",
+		mock.Anything,
+		"https://api.tinybird.co/v0/pipes/daily_snapshot/copy/pause",
+		[]byte(nil),
+		"",
+		"",
+		mock.AnythingOfType("*tinybird.CopyPipe"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(5).(*CopyPipe).Schedule = &CopySchedule{Cron: "0 0 * * *", Status: CopyScheduleShutdown}
+	})
+
+	pipe, err := client.PauseCopyPipe(context.Background(), "daily_snapshot")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pipe.Schedule.Status != CopyScheduleShutdown {
+		t.Errorf("Status = %s, want shutdown", pipe.Schedule.Status)
+	}
+}
+
+func TestListCopyRuns(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/jobs",
+		map[string]string{"kind": "copy", "pipe_name": "daily_snapshot"},
+		mock.Anything,
+	).Return(nil).Run(func(args mock.Arguments) {
+		response := args.Get(3).(*struct {
+			Jobs []Job `json:"jobs"`
+		})
+		response.Jobs = []Job{{JobID: "job-3"}, {JobID: "job-2"}, {JobID: "job-1"}}
+	})
+
+	jobs, err := client.ListCopyRuns(context.Background(), "daily_snapshot", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].ID != "job-3" {
+		t.Errorf("jobs = %+v, want the two most recent with IDs set", jobs)
+	}
+}
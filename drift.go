@@ -0,0 +1,539 @@
+package tinybird
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NOLLYWOOD-COM/tinybird/chtypes"
+	"github.com/NOLLYWOOD-COM/tinybird/internal/jsonpath"
+)
+
+const (
+	// DefaultDriftSampleRows is the number of events compared with the datasource schema per check.
+	DefaultDriftSampleRows = 100
+
+	// DefaultDriftSampleRate is the fraction of SendEvents calls checked for schema drift.
+	DefaultDriftSampleRate = 0.01
+
+	// DefaultDriftColumnsTTL is how long datasource columns are reused between drift checks.
+	DefaultDriftColumnsTTL = 5 * time.Minute
+
+	// DefaultDriftCheckTimeout bounds each background drift check, including the API calls it makes.
+	DefaultDriftCheckTimeout = 30 * time.Second
+)
+
+// jsonFieldPattern matches field names written with dotted JSONPath access
+var jsonFieldPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (c *ClientImpl) GetDatasourceColumns(ctx context.Context, datasourceName string) ([]DatasourceColumn, error) {
+	var response struct {
+		Schema struct {
+			Columns []DatasourceColumn `json:"columns"`
+		} `json:"schema"`
+	}
+
+	if err := c.httpClient.Get(ctx, c.apiURL("datasources/"+url.PathEscape(datasourceName)), nil, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Schema.Columns, nil
+}
+
+func (c *ClientImpl) CheckSchemaDrift(ctx context.Context, datasourceName string, data []byte, options *DriftCheckOptions) (*SchemaDrift, error) {
+	if options == nil {
+		options = &DriftCheckOptions{}
+	}
+
+	columns, err := c.GetDatasourceColumns(ctx, datasourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.compareSchema(ctx, datasourceName, columns, data, options)
+}
+
+// compareSchema compares a sample of events with the columns of a datasource
+func (c *ClientImpl) compareSchema(ctx context.Context, datasourceName string, columns []DatasourceColumn, data []byte, options *DriftCheckOptions) (*SchemaDrift, error) {
+	rows, err := sampleEvents(data, options)
+	if err != nil {
+		return nil, err
+	}
+
+	drift, err := detectDrift(datasourceName, columns, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Analyze && len(drift.NewPaths) > 0 {
+		if err := c.analyzeNewPaths(ctx, drift, rows); err != nil {
+			return nil, err
+		}
+	}
+
+	return drift, nil
+}
+
+// analyzeNewPaths replaces the locally inferred types of new paths with the types recommended by Analyze
+func (c *ClientImpl) analyzeNewPaths(ctx context.Context, drift *SchemaDrift, rows []interface{}) error {
+	var sample bytes.Buffer
+	encoder := json.NewEncoder(&sample)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return fmt.Errorf("failed to encode drift sample: %w", err)
+		}
+	}
+
+	analysis, err := c.AnalyzeWithOptions(ctx, sample.Bytes(), &AnalyzeOptions{Format: AnalyzeFormatNDJSON, SampleBytes: -1})
+	if err != nil {
+		return fmt.Errorf("failed to analyze drift sample: %w", err)
+	}
+
+	// Analyze may write field names in dotted form where NewPaths bracket them
+	recommended := make(map[string]string, len(analysis.Analysis.Columns))
+	for _, column := range analysis.Analysis.Columns {
+		recommended[canonicalPath(column.Path)] = column.RecommendedType
+	}
+	for i := range drift.NewPaths {
+		if typ, ok := recommended[canonicalPath(drift.NewPaths[i].Path)]; ok {
+			drift.NewPaths[i].Type = typ
+		}
+	}
+
+	return nil
+}
+
+// sampleEvents decodes up to options.SampleRows events from an NDJSON payload, or the single event of a JSON payload
+func sampleEvents(data []byte, options *DriftCheckOptions) ([]interface{}, error) {
+	limit := options.SampleRows
+	if limit == 0 {
+		limit = DefaultDriftSampleRows
+	}
+
+	var rows []interface{}
+	line := 0
+	for offset := 0; offset < len(data) && (limit < 0 || len(rows) < limit); {
+		end := len(data)
+		if i := bytes.IndexByte(data[offset:], '\n'); i >= 0 && options.Format != "json" {
+			end = offset + i
+		}
+		line++
+
+		if text := bytes.TrimSpace(data[offset:end]); len(text) > 0 {
+			decoder := json.NewDecoder(bytes.NewReader(text))
+			decoder.UseNumber()
+
+			var value interface{}
+			if err := decoder.Decode(&value); err != nil {
+				return nil, fmt.Errorf("invalid JSON on line %d: %w", line, err)
+			}
+			rows = append(rows, value)
+		}
+
+		offset = end + 1
+	}
+
+	return rows, nil
+}
+
+// driftColumn is a datasource column with its JSONPath parsed ahead of time
+type driftColumn struct {
+	DatasourceColumn
+	path     string
+	typ      *chtypes.Type
+	segments []jsonpath.Segment
+}
+
+// detectDrift compares decoded events with the columns of a datasource
+func detectDrift(datasourceName string, columns []DatasourceColumn, rows []interface{}) (*SchemaDrift, error) {
+	drift := &SchemaDrift{Datasource: datasourceName, SampledRows: len(rows)}
+
+	compiled := make([]driftColumn, 0, len(columns))
+	for _, column := range columns {
+		path := column.JSONPath
+		if path == "" {
+			// Columns computed from a DEFAULT expression are not read from events
+			if column.DefaultValue != "" {
+				continue
+			}
+			path = jsonFieldPath("$", column.Name)
+		}
+
+		segments, err := jsonpath.Parse(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid column %s: %w", column.Name, err)
+		}
+		typ, err := chtypes.Parse(column.Type)
+		if err != nil {
+			return nil, fmt.Errorf("invalid column %s: %w", column.Name, err)
+		}
+		compiled = append(compiled, driftColumn{DatasourceColumn: column, path: path, typ: typ, segments: segments})
+	}
+
+	// Paths present in the events, with the values seen for each
+	observed := map[string][]interface{}{}
+	for _, row := range rows {
+		collectPaths(row, "$", observed)
+	}
+
+	for _, column := range compiled {
+		present, mismatched := 0, 0
+		message := ""
+		for _, row := range rows {
+			value, ok := jsonpath.Eval(row, column.segments)
+			if !ok {
+				continue
+			}
+			present++
+			if problem := checkValue(column.typ, value); problem != "" {
+				if mismatched == 0 {
+					message = problem
+				}
+				mismatched++
+			}
+		}
+
+		switch {
+		case present == 0 && len(rows) > 0:
+			drift.MissingColumns = append(drift.MissingColumns, DriftColumn{Column: column.Name, Path: column.path, Type: column.Type})
+		case mismatched > 0:
+			drift.TypeChanges = append(drift.TypeChanges, DriftTypeChange{
+				Column:  column.Name,
+				Path:    column.path,
+				Type:    column.Type,
+				Message: message,
+				Rows:    mismatched,
+			})
+		}
+	}
+
+	for path, values := range observed {
+		segments, err := jsonpath.Parse(path)
+		if err != nil || coveredPath(segments, compiled) {
+			continue
+		}
+		drift.NewPaths = append(drift.NewPaths, DriftPath{Path: path, Type: inferColumnType(values), Rows: len(values)})
+	}
+	sort.Slice(drift.NewPaths, func(i, j int) bool { return drift.NewPaths[i].Path < drift.NewPaths[j].Path })
+
+	return drift, nil
+}
+
+// collectPaths records the JSONPath of every leaf value under path. Arrays of
+// scalars are leaves at path[:], as they are written in datasource schemas.
+// Empty arrays and objects hold no data that could be dropped and are skipped.
+func collectPaths(value interface{}, path string, observed map[string][]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			collectPaths(child, jsonFieldPath(path, key), observed)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return
+		}
+		for _, elem := range v {
+			if _, ok := elem.(map[string]interface{}); !ok {
+				observed[path+"[:]"] = append(observed[path+"[:]"], v)
+				return
+			}
+		}
+		for _, elem := range v {
+			collectPaths(elem, path+"[:]", observed)
+		}
+	default:
+		observed[path] = append(observed[path], v)
+	}
+}
+
+// coveredPath reports whether a column reads the value at segments, or an object or array containing it
+func coveredPath(segments []jsonpath.Segment, columns []driftColumn) bool {
+	for _, column := range columns {
+		if len(column.segments) > len(segments) {
+			continue
+		}
+		covered := true
+		for i, segment := range column.segments {
+			if segment != segments[i] {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonFieldPath appends a field access to a JSONPath, bracketed when the name needs it
+func jsonFieldPath(path string, field string) string {
+	if jsonFieldPattern.MatchString(field) {
+		return path + "." + field
+	}
+	return path + "['" + field + "']"
+}
+
+// canonicalPath rewrites a JSONPath in the form jsonFieldPath produces, or returns
+// it as is if it cannot be parsed
+func canonicalPath(path string) string {
+	segments, err := jsonpath.Parse(path)
+	if err != nil {
+		return path
+	}
+
+	canonical := "$"
+	for _, segment := range segments {
+		if segment.Wildcard {
+			canonical += "[:]"
+		} else {
+			canonical = jsonFieldPath(canonical, segment.Field)
+		}
+	}
+	return canonical
+}
+
+// inferColumnType suggests a ClickHouse type for the values seen at a path
+func inferColumnType(values []interface{}) string {
+	typ, nullable := "", false
+	for _, value := range values {
+		if value == nil {
+			nullable = true
+			continue
+		}
+		if typ == "" {
+			typ = inferValueType(value)
+		}
+	}
+
+	if typ == "" {
+		return "Nullable(String)"
+	}
+	if nullable && !strings.HasPrefix(typ, "Array(") {
+		return "Nullable(" + typ + ")"
+	}
+	return typ
+}
+
+func inferValueType(value interface{}) string {
+	switch v := value.(type) {
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "Int64"
+		}
+		return "Float64"
+	case bool:
+		return "Bool"
+	case string:
+		if !isDateValue(v) {
+			return "String"
+		}
+		if len(v) == len("2006-01-02") {
+			return "Date"
+		}
+		return "DateTime64(3)"
+	case []interface{}:
+		for _, elem := range v {
+			if elem != nil {
+				return "Array(" + inferValueType(elem) + ")"
+			}
+		}
+		return "Array(String)"
+	}
+	return "String"
+}
+
+// driftMonitor checks a sample of SendEvents payloads for schema drift in the background
+type driftMonitor struct {
+	client       *ClientImpl
+	sampleRate   float64
+	sampleRows   int
+	columnsTTL   time.Duration
+	checkTimeout time.Duration
+	analyze      bool
+	onDrift      func(drift *SchemaDrift)
+	onError      func(err error)
+
+	// ctx is cancelled by close to abort running checks
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	columns  map[string]cachedColumns
+	inFlight map[string]bool
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// cachedColumns are the columns of a datasource and when they were fetched
+type cachedColumns struct {
+	columns []DatasourceColumn
+	fetched time.Time
+}
+
+func newDriftMonitor(client *ClientImpl, options *SchemaDriftOptions) *driftMonitor {
+	sampleRate := options.SampleRate
+	if sampleRate <= 0 {
+		sampleRate = DefaultDriftSampleRate
+	}
+
+	sampleRows := options.SampleRows
+	if sampleRows <= 0 {
+		sampleRows = DefaultDriftSampleRows
+	}
+
+	columnsTTL := options.ColumnsTTL
+	if columnsTTL <= 0 {
+		columnsTTL = DefaultDriftColumnsTTL
+	}
+
+	checkTimeout := options.CheckTimeout
+	if checkTimeout <= 0 {
+		checkTimeout = DefaultDriftCheckTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &driftMonitor{
+		client:       client,
+		sampleRate:   sampleRate,
+		sampleRows:   sampleRows,
+		columnsTTL:   columnsTTL,
+		checkTimeout: checkTimeout,
+		analyze:      options.Analyze,
+		onDrift:      options.OnDrift,
+		onError:      options.OnError,
+		ctx:          ctx,
+		cancel:       cancel,
+		columns:      map[string]cachedColumns{},
+		inFlight:     map[string]bool{},
+	}
+}
+
+// observe starts a drift check of data for a sampled fraction of calls, at most
+// one at a time per datasource
+func (m *driftMonitor) observe(datasourceName string, data []byte, options *SendEventsOptions) {
+	if rand.Float64() >= m.sampleRate {
+		return
+	}
+
+	m.mu.Lock()
+	if m.closed || m.inFlight[datasourceName] {
+		m.mu.Unlock()
+		return
+	}
+	m.inFlight[datasourceName] = true
+	m.wg.Add(1)
+	m.mu.Unlock()
+
+	// The caller may reuse data once SendEvents returns. A json payload is a
+	// single document, so only NDJSON can be cut to its first lines.
+	sample := data
+	if options.Format != "json" {
+		sample = firstLines(data, m.sampleRows)
+	}
+	sample = append([]byte(nil), sample...)
+
+	go func() {
+		defer m.wg.Done()
+
+		drift, err := m.check(datasourceName, sample, options.Format)
+
+		m.mu.Lock()
+		delete(m.inFlight, datasourceName)
+		m.mu.Unlock()
+
+		if err != nil {
+			// Checks aborted by close are not failures
+			if m.ctx.Err() != nil {
+				return
+			}
+			if m.onError != nil {
+				m.onError(fmt.Errorf("schema drift check of %s failed: %w", datasourceName, err))
+			}
+			return
+		}
+		if drift.HasDrift() && m.onDrift != nil {
+			m.onDrift(drift)
+		}
+	}()
+}
+
+func (m *driftMonitor) check(datasourceName string, sample []byte, format string) (*SchemaDrift, error) {
+	ctx, cancel := context.WithTimeout(m.ctx, m.checkTimeout)
+	defer cancel()
+
+	m.mu.Lock()
+	cached, ok := m.columns[datasourceName]
+	m.mu.Unlock()
+
+	if !ok || time.Since(cached.fetched) > m.columnsTTL {
+		columns, err := m.client.GetDatasourceColumns(ctx, datasourceName)
+		if err != nil {
+			return nil, err
+		}
+		cached = cachedColumns{columns: columns, fetched: time.Now()}
+
+		m.mu.Lock()
+		m.columns[datasourceName] = cached
+		m.mu.Unlock()
+	}
+
+	return m.client.compareSchema(ctx, datasourceName, cached.columns, sample, &DriftCheckOptions{
+		SampleRows: m.sampleRows,
+		Analyze:    m.analyze,
+		Format:     format,
+	})
+}
+
+// close stops new checks, cancels running ones and waits for them to return
+func (m *driftMonitor) close() {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+
+	m.cancel()
+	m.wg.Wait()
+}
+
+// firstLines returns the first n lines of an NDJSON payload
+func firstLines(data []byte, n int) []byte {
+	offset := 0
+	for i := 0; i < n; i++ {
+		next := bytes.IndexByte(data[offset:], '\n')
+		if next < 0 {
+			return data
+		}
+		offset += next + 1
+	}
+	return data[:offset]
+}
+
+// HasDrift reports whether the events differ from the datasource schema
+func (d *SchemaDrift) HasDrift() bool {
+	return len(d.NewPaths) > 0 || len(d.TypeChanges) > 0 || len(d.MissingColumns) > 0
+}
+
+func (d *SchemaDrift) String() string {
+	newPaths := make([]string, len(d.NewPaths))
+	for i, path := range d.NewPaths {
+		newPaths[i] = path.Path + " " + path.Type
+	}
+	typeChanges := make([]string, len(d.TypeChanges))
+	for i, change := range d.TypeChanges {
+		typeChanges[i] = change.Column + ": " + change.Message
+	}
+	missing := make([]string, len(d.MissingColumns))
+	for i, column := range d.MissingColumns {
+		missing[i] = column.Column
+	}
+	return fmt.Sprintf("%s: new paths %v, type changes %v, missing columns %v", d.Datasource, newPaths, typeChanges, missing)
+}
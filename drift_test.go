@@ -0,0 +1,261 @@
+package tinybird
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func mockDatasourceColumns(mockClient *MockHttpClient, name string, columns ...DatasourceColumn) *mock.Call {
+	return mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/datasources/"+name,
+		mock.Anything,
+		mock.Anything,
+	).Return(nil).Run(func(args mock.Arguments) {
+		response := args.Get(3).(*struct {
+			Schema struct {
+				Columns []DatasourceColumn `json:"columns"`
+			} `json:"schema"`
+		})
+		response.Schema.Columns = columns
+	})
+}
+
+func pageViewColumns() []DatasourceColumn {
+	return []DatasourceColumn{
+		{Name: "timestamp", Type: "DateTime64(3)", JSONPath: "$.timestamp"},
+		{Name: "user_id", Type: "UInt64", JSONPath: "$.user.id"},
+		{Name: "tags", Type: "Array(String)", JSONPath: "$.tags[:]"},
+		{Name: "referrer", Type: "Nullable(String)", JSONPath: "$.referrer"},
+		{Name: "day", Type: "Date", DefaultValue: "toDate(timestamp)"},
+	}
+}
+
+func TestCheckSchemaDrift(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+	mockDatasourceColumns(mockClient, "page_views", pageViewColumns()...)
+
+	data := []byte(`{"timestamp":"2024-05-01 10:00:00","user":{"id":"abc","plan":"pro"},"tags":["a"],"items":[{"sku":"x1","qty":2}]}
+{"timestamp":"2024-05-01 10:00:01","user":{"id":42,"plan":null},"tags":[],"items":[]}
+`)
+
+	drift, err := client.CheckSchemaDrift(context.Background(), "page_views", data, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !drift.HasDrift() || drift.SampledRows != 2 {
+		t.Fatalf("unexpected drift: %s", drift)
+	}
+
+	expectedPaths := []DriftPath{
+		{Path: "$.items[:].qty", Type: "Int64", Rows: 1},
+		{Path: "$.items[:].sku", Type: "String", Rows: 1},
+		{Path: "$.user.plan", Type: "Nullable(String)", Rows: 2},
+	}
+	if len(drift.NewPaths) != len(expectedPaths) {
+		t.Fatalf("NewPaths = %+v, want %+v", drift.NewPaths, expectedPaths)
+	}
+	for i, expected := range expectedPaths {
+		if drift.NewPaths[i] != expected {
+			t.Errorf("NewPaths[%d] = %+v, want %+v", i, drift.NewPaths[i], expected)
+		}
+	}
+
+	if len(drift.TypeChanges) != 1 || drift.TypeChanges[0].Column != "user_id" || drift.TypeChanges[0].Rows != 1 {
+		t.Errorf("TypeChanges = %+v", drift.TypeChanges)
+	}
+
+	// Computed columns are not expected in events
+	if len(drift.MissingColumns) != 1 || drift.MissingColumns[0].Column != "referrer" {
+		t.Errorf("MissingColumns = %+v", drift.MissingColumns)
+	}
+}
+
+func TestCheckSchemaDrift_NoDrift(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+	mockDatasourceColumns(mockClient, "page_views", pageViewColumns()...)
+
+	data := []byte(`{"timestamp":"2024-05-01T10:00:00Z","user":{"id":1},"tags":["a","b"],"referrer":null}`)
+
+	drift, err := client.CheckSchemaDrift(context.Background(), "page_views", data, &DriftCheckOptions{Format: "json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if drift.HasDrift() {
+		t.Errorf("unexpected drift: %s", drift)
+	}
+}
+
+func TestCheckSchemaDrift_AnalyzeNewPaths(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+	mockDatasourceColumns(mockClient, "page_views", pageViewColumns()...)
+
+	mockClient.On("PostMultipart", mock.Anything, mock.Anything, "file", "data.ndjson", mock.Anything, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			response := args.Get(5).(*AnalyzeResponse)
+			response.Analysis.Columns = []ColumnAnalysis{
+				{Path: "$.user.plan", RecommendedType: "LowCardinality(String)", Name: "user_plan"},
+				{Path: "$.user.sign-up.source", RecommendedType: "LowCardinality(String)", Name: "user_sign_up_source"},
+			}
+		})
+
+	data := []byte(`{"timestamp":"2024-05-01 10:00:00","user":{"id":1,"plan":"pro","sign-up":{"source":"ads"}},"tags":[],"referrer":null}`)
+
+	drift, err := client.CheckSchemaDrift(context.Background(), "page_views", data, &DriftCheckOptions{Analyze: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Bracketed field names match the dotted form Analyze reports
+	expected := []DriftPath{
+		{Path: "$.user.plan", Type: "LowCardinality(String)", Rows: 1},
+		{Path: "$.user['sign-up'].source", Type: "LowCardinality(String)", Rows: 1},
+	}
+	if len(drift.NewPaths) != len(expected) {
+		t.Fatalf("NewPaths = %+v, want %+v", drift.NewPaths, expected)
+	}
+	for i := range expected {
+		if drift.NewPaths[i] != expected[i] {
+			t.Errorf("NewPaths[%d] = %+v, want %+v", i, drift.NewPaths[i], expected[i])
+		}
+	}
+}
+
+func newDriftTestClient(mockClient *MockHttpClient, drift *SchemaDriftOptions) *ClientImpl {
+	options := &ClientOptions{
+		Protocol:       "https",
+		Host:           "api.tinybird.co",
+		ApiVersion:     "v0",
+		Token:          "test-token",
+		DriftDetection: drift,
+	}
+	return NewClient(options, mockClient).(*ClientImpl)
+}
+
+func TestSendEvents_DriftDetection(t *testing.T) {
+	mockClient := NewMockHttpClient()
+
+	drifts := make(chan *SchemaDrift, 2)
+	client := newDriftTestClient(mockClient, &SchemaDriftOptions{
+		SampleRate: 1,
+		ColumnsTTL: time.Hour,
+		OnDrift:    func(drift *SchemaDrift) { drifts <- drift },
+		OnError:    func(err error) { t.Errorf("unexpected drift check error: %v", err) },
+	})
+
+	mockDatasourceColumns(mockClient, "page_views", pageViewColumns()...)
+	mockClient.On("PostRaw", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	data := []byte(`{"timestamp":"2024-05-01 10:00:00","user":{"id":1,"country":"ES"},"tags":[],"referrer":null}` + "\n")
+	for i := 0; i < 2; i++ {
+		if _, err := client.SendEvents(context.Background(), "page_views", data, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// Wait for the check, so the second call is not skipped as in flight
+		select {
+		case drift := <-drifts:
+			if len(drift.NewPaths) != 1 || drift.NewPaths[0].Path != "$.user.country" {
+				t.Errorf("NewPaths = %+v", drift.NewPaths)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("drift was not reported")
+		}
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Columns are fetched once and reused within ColumnsTTL
+	mockClient.AssertNumberOfCalls(t, "Get", 1)
+}
+
+func TestSchemaDrift_String(t *testing.T) {
+	drift := &SchemaDrift{
+		Datasource:     "page_views",
+		NewPaths:       []DriftPath{{Path: "$.user.plan", Type: "String"}},
+		MissingColumns: []DriftColumn{{Column: "referrer"}},
+	}
+
+	s := drift.String()
+	for _, expected := range []string{"page_views", "$.user.plan String", "[referrer]"} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("String() = %q, missing %q", s, expected)
+		}
+	}
+}
+
+func TestSendEvents_DriftDetectionJSONFormat(t *testing.T) {
+	mockClient := NewMockHttpClient()
+
+	drifts := make(chan *SchemaDrift, 1)
+	client := newDriftTestClient(mockClient, &SchemaDriftOptions{
+		SampleRate: 1,
+		SampleRows: 1,
+		OnDrift:    func(drift *SchemaDrift) { drifts <- drift },
+		OnError:    func(err error) { t.Errorf("unexpected drift check error: %v", err) },
+	})
+	defer client.Close()
+
+	mockDatasourceColumns(mockClient, "page_views", pageViewColumns()...)
+	mockClient.On("PostRaw", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// A pretty-printed document spans many lines but is a single event
+	data := []byte("{\n  \"timestamp\": \"2024-05-01 10:00:00\",\n  \"user\": {\"id\": 1, \"country\": \"ES\"},\n  \"tags\": [],\n  \"referrer\": null\n}\n")
+	if _, err := client.SendEvents(context.Background(), "page_views", data, &SendEventsOptions{Format: "json"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case drift := <-drifts:
+		if drift.SampledRows != 1 || len(drift.NewPaths) != 1 || drift.NewPaths[0].Path != "$.user.country" {
+			t.Errorf("unexpected drift: %s", drift)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("drift was not reported")
+	}
+}
+
+func TestSendEvents_DriftDetectionCloseCancelsChecks(t *testing.T) {
+	mockClient := NewMockHttpClient()
+
+	client := newDriftTestClient(mockClient, &SchemaDriftOptions{
+		SampleRate: 1,
+		OnError:    func(err error) { t.Errorf("unexpected drift check error: %v", err) },
+	})
+
+	started := make(chan struct{})
+	mockClient.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(context.Canceled).
+		Run(func(args mock.Arguments) {
+			close(started)
+			<-args.Get(0).(context.Context).Done()
+		})
+	mockClient.On("PostRaw", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	if _, err := client.SendEvents(context.Background(), "page_views", []byte(`{"a":1}`), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-started
+
+	closed := make(chan error, 1)
+	go func() { closed <- client.Close() }()
+
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not cancel the running check")
+	}
+}
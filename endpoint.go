@@ -1,11 +1,25 @@
 package tinybird
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/url"
+
+	"github.com/NOLLYWOOD-COM/tinybird/chtypes"
 )
 
-func (c *ClientImpl) CallEndpoint(ctx context.Context, endpointName string, params map[string]string) (*EndpointResponse, error) {
+// DefaultEndpointPostThreshold is the encoded query string length above which
+// CallEndpoint sends parameters in a POST body, keeping URLs well below common limits.
+const DefaultEndpointPostThreshold = 4096
+
+func (c *ClientImpl) CallEndpoint(ctx context.Context, endpointName string, params map[string]string, callOptions ...CallOption) (*EndpointResponse, error) {
+	call := newCallOptions(callOptions)
+
+	ctx, cancel := call.context(ctx)
+	defer cancel()
+
 	reqUrl := fmt.Sprintf("%s://%s/%s/pipes/%s",
 		c.options.Protocol,
 		c.options.Host,
@@ -13,12 +27,165 @@ func (c *ClientImpl) CallEndpoint(ctx context.Context, endpointName string, para
 		endpointName,
 	)
 
-	var response EndpointResponse
+	fetch := func(ctx context.Context) (*EndpointResponse, error) {
+		var response EndpointResponse
+
+		var err error
+		if c.postParams(call, params) {
+			err = c.httpClient.Post(ctx, reqUrl, params, &response)
+		} else {
+			err = c.httpClient.Get(ctx, reqUrl, params, &response)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &response, nil
+	}
+
+	if c.hedger != nil {
+		request := fetch
+		fetch = func(ctx context.Context) (*EndpointResponse, error) {
+			return c.hedger.do(ctx, endpointName, request)
+		}
+	}
+
+	if c.cache == nil && c.calls == nil {
+		return fetch(ctx)
+	}
+
+	key := endpointCallKey(c.options, call, endpointName, params)
+
+	// Cache misses are coalesced too, so a cold key costs a single request
+	if c.calls != nil {
+		request := fetch
+		fetch = func(ctx context.Context) (*EndpointResponse, error) {
+			return c.calls.do(ctx, key, request)
+		}
+	}
+
+	if c.cache != nil {
+		return c.cache.get(ctx, endpointName, key, fetch)
+	}
+
+	return fetch(ctx)
+}
+
+// postParams reports whether endpoint parameters are sent as a POST body rather than a query string
+func (c *ClientImpl) postParams(call *callOptions, params map[string]string) bool {
+	switch call.paramMode {
+	case ParamsQuery:
+		return false
+	case ParamsBody:
+		return true
+	}
+
+	threshold := c.options.EndpointPostThreshold
+	if threshold == 0 {
+		threshold = DefaultEndpointPostThreshold
+	}
+	if threshold < 0 || len(params) == 0 {
+		return false
+	}
+
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	return len(values.Encode()) > threshold
+}
+
+// UnmarshalJSON decodes the response and keeps its data for DecodedRows, since
+// Data holds numbers as float64, which cannot represent every Int64 or Decimal.
+func (r *EndpointResponse) UnmarshalJSON(data []byte) error {
+	type endpointResponse EndpointResponse
+	var response struct {
+		*endpointResponse
+		RawData json.RawMessage `json:"data"`
+	}
+	response.endpointResponse = (*endpointResponse)(r)
+	if err := json.Unmarshal(data, &response); err != nil {
+		return err
+	}
+
+	r.Data = nil
+	r.rawData = nil
+	if len(response.RawData) == 0 || string(response.RawData) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(response.RawData, &r.Data); err != nil {
+		return err
+	}
+	r.rawData = response.RawData
+	return nil
+}
+
+// MarshalJSON writes the data as it was received, so that a response stored and
+// decoded again, as the endpoint cache does, still has exact numbers for DecodedRows.
+func (r EndpointResponse) MarshalJSON() ([]byte, error) {
+	type endpointResponse EndpointResponse
+	if r.rawData == nil {
+		return json.Marshal(endpointResponse(r))
+	}
+
+	return json.Marshal(struct {
+		endpointResponse
+		RawData json.RawMessage `json:"data"`
+	}{endpointResponse(r), r.rawData})
+}
+
+// DecodedRows returns the rows of the response with each value decoded to the Go type
+// of its column, as described in package chtypes. Columns without meta are returned as is.
+// Numbers are decoded from the response body, so 64-bit integers and decimals are exact.
+func (r *EndpointResponse) DecodedRows() ([]map[string]interface{}, error) {
+	types := make(map[string]*chtypes.Type, len(r.Meta))
+	for _, meta := range r.Meta {
+		typ, err := chtypes.Parse(meta.Type)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", meta.Name, err)
+		}
+		types[meta.Name] = typ
+	}
 
-	err := c.httpClient.Get(ctx, reqUrl, params, &response)
+	data, err := r.exactData()
 	if err != nil {
 		return nil, err
 	}
 
-	return &response, nil
+	rows := make([]map[string]interface{}, len(data))
+	for i, data := range data {
+		row := make(map[string]interface{}, len(data))
+		for name, value := range data {
+			typ, ok := types[name]
+			if !ok {
+				row[name] = value
+				continue
+			}
+
+			decoded, err := typ.Decode(value)
+			if err != nil {
+				return nil, fmt.Errorf("row %d, column %s: %w", i, name, err)
+			}
+			row[name] = decoded
+		}
+		rows[i] = row
+	}
+
+	return rows, nil
+}
+
+// exactData returns the rows of the response with numbers as json.Number
+func (r *EndpointResponse) exactData() ([]map[string]interface{}, error) {
+	if r.rawData == nil {
+		return r.Data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(r.rawData))
+	decoder.UseNumber()
+
+	var data []map[string]interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode response data: %w", err)
+	}
+	return data, nil
 }
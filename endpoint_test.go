@@ -2,9 +2,13 @@ package tinybird
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/NOLLYWOOD-COM/tinybird/chtypes"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -154,3 +158,122 @@ func TestCallEndpoint_ContextCancellation(t *testing.T) {
 
 	mockClient.AssertExpectations(t)
 }
+
+func TestCallEndpoint_LargeParamsUsePost(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+	client.options.EndpointPostThreshold = 64
+
+	expectedURL := "https://api.tinybird.co/v0/pipes/filtered"
+	small := map[string]string{"ids": "1,2,3"}
+	large := map[string]string{"ids": strings.Repeat("item,", 20)}
+
+	mockClient.On("Get", mock.Anything, expectedURL, small, mock.AnythingOfType("*tinybird.EndpointResponse")).Return(nil)
+	mockClient.On("Post", mock.Anything, expectedURL, large, mock.AnythingOfType("*tinybird.EndpointResponse")).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(3).(*EndpointResponse).Rows = 20
+	})
+
+	if _, err := client.CallEndpoint(context.Background(), "filtered", small); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	response, err := client.CallEndpoint(context.Background(), "filtered", large)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Rows != 20 {
+		t.Errorf("Rows = %d, want 20", response.Rows)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestCallEndpoint_ForceQueryParams(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+	client.options.EndpointPostThreshold = 8
+
+	params := map[string]string{"ids": strings.Repeat("item,", 20)}
+	mockClient.On("Get", mock.Anything, mock.Anything, params, mock.Anything).Return(nil)
+
+	if _, err := client.CallEndpoint(context.Background(), "filtered", params, WithParamMode(ParamsQuery)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestEndpointResponse_DecodedRows(t *testing.T) {
+	body := []byte(`{
+		"meta": [
+			{"name": "hits", "type": "UInt64"},
+			{"name": "total", "type": "Int64"},
+			{"name": "price", "type": "Decimal(18, 2)"},
+			{"name": "day", "type": "Date"},
+			{"name": "country", "type": "LowCardinality(Nullable(String))"}
+		],
+		"data": [
+			{"hits": "18446744073709551615", "total": 9007199254740993, "price": 0.30, "day": "2024-05-01", "country": null, "extra": "x"}
+		],
+		"rows": 1
+	}`)
+
+	var response EndpointResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Data keeps numbers as float64
+	if _, ok := response.Data[0]["total"].(float64); !ok || response.Rows != 1 || len(response.Meta) != 5 {
+		t.Fatalf("unexpected response: %+v", response)
+	}
+
+	rows, err := response.DecodedRows()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	row := rows[0]
+	if row["hits"] != uint64(18446744073709551615) {
+		t.Errorf("hits = %#v", row["hits"])
+	}
+	if row["total"] != int64(9007199254740993) {
+		t.Errorf("total = %#v, want 9007199254740993", row["total"])
+	}
+	if price, ok := row["price"].(chtypes.Decimal); !ok || price.String() != "0.30" {
+		t.Errorf("price = %#v", row["price"])
+	}
+	if day, ok := row["day"].(time.Time); !ok || !day.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("day = %#v", row["day"])
+	}
+	if country, ok := row["country"].(*string); !ok || country != nil {
+		t.Errorf("country = %#v", row["country"])
+	}
+	if row["extra"] != "x" {
+		t.Errorf("extra = %#v", row["extra"])
+	}
+}
+
+func TestEndpointResponse_DecodedRowsFloat(t *testing.T) {
+	// Rows built in code rather than decoded from a response keep their float64 values
+	response := &EndpointResponse{
+		Meta: []FieldMeta{{Name: "total", Type: "Int64"}},
+		Data: []map[string]interface{}{{"total": float64(42)}},
+	}
+
+	if _, err := response.DecodedRows(); err == nil || !strings.Contains(err.Error(), "UseNumber") {
+		t.Errorf("expected a precision error, got %v", err)
+	}
+}
+
+func TestEndpointResponse_DecodedRowsError(t *testing.T) {
+	response := &EndpointResponse{
+		Meta: []FieldMeta{{Name: "hits", Type: "UInt8"}},
+		Data: []map[string]interface{}{{"hits": float64(1)}, {"hits": float64(300)}},
+	}
+
+	_, err := response.DecodedRows()
+	if err == nil || !strings.Contains(err.Error(), "row 1, column hits") {
+		t.Fatalf("expected a row error, got %v", err)
+	}
+}
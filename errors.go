@@ -0,0 +1,31 @@
+package tinybird
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NOLLYWOOD-COM/tinybird/internal/httpclient"
+)
+
+// HTTPError is returned when the Tinybird API responds with a non-2xx status code.
+type HTTPError = httpclient.HTTPError
+
+// ErrMaxRetriesExceeded is wrapped by errors returned after every retry attempt failed.
+var ErrMaxRetriesExceeded = httpclient.ErrMaxRetriesExceeded
+
+// ErrCircuitOpen is wrapped by errors returned without contacting the API because a circuit breaker is open.
+var ErrCircuitOpen = httpclient.ErrCircuitOpen
+
+// ErrRateLimited is wrapped by errors returned in fail-fast mode when a request would exceed a client-side rate limit.
+var ErrRateLimited = httpclient.ErrRateLimited
+
+// IsRetryable reports whether err is a transient failure, such as a network error,
+// a 5xx/429 response that persisted through every retry, an open circuit breaker
+// or a client-side rate limit, as opposed to a request the API rejected or a
+// context the caller cancelled.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return errors.Is(err, ErrMaxRetriesExceeded) || errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrRateLimited)
+}
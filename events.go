@@ -1,16 +1,17 @@
 package tinybird
 
 import (
-	"bytes"
-	"compress/gzip"
 	"context"
 	"fmt"
 	"net/url"
 
-	"github.com/klauspost/compress/zstd"
+	"github.com/NOLLYWOOD-COM/tinybird/internal/httpclient"
 )
 
-func (c *ClientImpl) SendEvents(ctx context.Context, datasourceName string, data []byte, options *SendEventsOptions) (*WriteResponse, error) {
+func (c *ClientImpl) SendEvents(ctx context.Context, datasourceName string, data []byte, options *SendEventsOptions, callOptions ...CallOption) (*WriteResponse, error) {
+	ctx, cancel := newCallOptions(callOptions).context(ctx)
+	defer cancel()
+
 	if options == nil {
 		options = &SendEventsOptions{
 			Wait:     false,
@@ -19,7 +20,61 @@ func (c *ClientImpl) SendEvents(ctx context.Context, datasourceName string, data
 		}
 	}
 
-	// Build the URL for sending events
+	if options.Validate {
+		if err := validateEvents(data, options); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.drift != nil {
+		c.drift.observe(datasourceName, data, options)
+	}
+
+	// Split the payload into request-sized chunks, compressing each one if requested
+	chunks, err := prepareChunks(data, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chunks) == 1 {
+		return c.sendChunk(ctx, datasourceName, chunks[0], options)
+	}
+
+	return c.sendChunks(ctx, datasourceName, chunks, options)
+}
+
+// sendChunk sends a single prepared request body to the Events API, spooling it
+// to disk for later delivery if the failure is transient and a spool is configured.
+// Spooled payloads are replayed with the client's token, so calls made with
+// WithToken are never spooled.
+func (c *ClientImpl) sendChunk(ctx context.Context, datasourceName string, chunk payloadChunk, options *SendEventsOptions) (*WriteResponse, error) {
+	response, err := c.postEvents(ctx, datasourceName, chunk.body, options)
+	if err != nil && c.spool != nil && IsRetryable(err) && httpclient.RequestOptionsFrom(ctx).Token == "" {
+		return nil, c.spool.enqueue(datasourceName, chunk.body, options, err)
+	}
+
+	return response, err
+}
+
+// postEvents posts a prepared, possibly compressed, body to the Events API
+func (c *ClientImpl) postEvents(ctx context.Context, datasourceName string, body []byte, options *SendEventsOptions) (*WriteResponse, error) {
+	contentEncoding := ""
+	if options.Compress {
+		contentEncoding = compressionEncoding(options)
+	}
+
+	var response WriteResponse
+
+	err := c.httpClient.PostRaw(ctx, c.eventsURL(datasourceName, options), body, eventsContentType(options), contentEncoding, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// eventsURL builds the Events API URL for the given datasource and options
+func (c *ClientImpl) eventsURL(datasourceName string, options *SendEventsOptions) string {
 	reqUrl := fmt.Sprintf("%s://%s/%s/%s?name=%s",
 		c.options.Protocol,
 		c.options.Host,
@@ -36,52 +91,21 @@ func (c *ClientImpl) SendEvents(ctx context.Context, datasourceName string, data
 		reqUrl += "&format=" + url.QueryEscape(options.Format)
 	}
 
-	// Prepare request body and content encoding
-	body := data
-	contentEncoding := ""
-
-	if options.Compress {
-		encoding := options.CompressionEncoding
-		if encoding == "" {
-			encoding = "gzip"
-		}
-
-		switch encoding {
-		case "gzip":
-			var buf bytes.Buffer
-			gzWriter := gzip.NewWriter(&buf)
-			if _, err := gzWriter.Write(data); err != nil {
-				return nil, fmt.Errorf("failed to compress data: %w", err)
-			}
-			if err := gzWriter.Close(); err != nil {
-				return nil, fmt.Errorf("failed to close gzip writer: %w", err)
-			}
-			body = buf.Bytes()
-		case "zstd":
-			encoder, err := zstd.NewWriter(nil)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
-			}
-			body = encoder.EncodeAll(data, nil)
-			encoder.Close()
-		default:
-			return nil, fmt.Errorf("unsupported compression encoding: %s", encoding)
-		}
-		contentEncoding = encoding
-	}
+	return reqUrl
+}
 
-	// Set content type based on format
-	contentType := "application/x-ndjson"
+// eventsContentType returns the content type matching the payload format
+func eventsContentType(options *SendEventsOptions) string {
 	if options.Format == "json" {
-		contentType = "application/json"
+		return "application/json"
 	}
+	return "application/x-ndjson"
+}
 
-	var response WriteResponse
-
-	err := c.httpClient.PostRaw(ctx, reqUrl, body, contentType, contentEncoding, &response)
-	if err != nil {
-		return nil, err
+// compressionEncoding returns the configured compression encoding, defaulting to gzip
+func compressionEncoding(options *SendEventsOptions) string {
+	if options.CompressionEncoding == "" {
+		return "gzip"
 	}
-
-	return &response, nil
+	return options.CompressionEncoding
 }
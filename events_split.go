@@ -0,0 +1,242 @@
+package tinybird
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxPayloadSize is the default maximum size in bytes of a single Events API request body.
+const DefaultMaxPayloadSize = 10 * 1024 * 1024
+
+// SendEventsError is returned by SendEvents when a payload was split into several
+// requests and at least one of them failed. Rows from the chunks that succeeded are
+// still counted in the WriteResponse returned alongside the error.
+type SendEventsError struct {
+	Chunks      []ChunkError // The chunks that failed to send
+	TotalChunks int          // The number of chunks the payload was split into
+}
+
+// ChunkError describes a failed chunk of a split payload.
+type ChunkError struct {
+	Index     int   // Zero-based index of the chunk
+	FirstLine int   // One-based number of the first NDJSON line in the chunk
+	LastLine  int   // One-based number of the last NDJSON line in the chunk
+	Err       error // The error returned when sending the chunk
+}
+
+func (e *SendEventsError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "failed to send %d of %d chunks", len(e.Chunks), e.TotalChunks)
+	for _, chunk := range e.Chunks {
+		fmt.Fprintf(&sb, "; chunk %d (lines %d-%d): %v", chunk.Index, chunk.FirstLine, chunk.LastLine, chunk.Err)
+	}
+	return sb.String()
+}
+
+func (e *SendEventsError) Unwrap() []error {
+	errs := make([]error, len(e.Chunks))
+	for i, chunk := range e.Chunks {
+		errs[i] = chunk.Err
+	}
+	return errs
+}
+
+// payloadChunk is a request body covering a range of NDJSON lines
+type payloadChunk struct {
+	body      []byte
+	firstLine int
+	lastLine  int
+}
+
+// maxPayloadSize returns the effective payload limit, or 0 if splitting is disabled
+func maxPayloadSize(options *SendEventsOptions) int {
+	switch {
+	case options.MaxPayloadSize < 0:
+		return 0
+	case options.MaxPayloadSize == 0:
+		return DefaultMaxPayloadSize
+	default:
+		return options.MaxPayloadSize
+	}
+}
+
+// prepareChunks splits data on line boundaries so that every chunk stays within the
+// payload limit both before and after compression, and compresses each chunk.
+func prepareChunks(data []byte, options *SendEventsOptions) ([]payloadChunk, error) {
+	limit := maxPayloadSize(options)
+
+	var chunks []payloadChunk
+	if limit == 0 || len(data) <= limit {
+		chunks = []payloadChunk{{body: data, firstLine: 1, lastLine: countLines(data)}}
+	} else {
+		if options.Format == "json" {
+			return nil, fmt.Errorf("payload of %d bytes exceeds the maximum payload size of %d bytes and cannot be split in json format", len(data), limit)
+		}
+
+		var err error
+		chunks, err = splitLines(data, 1, limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !options.Compress {
+		return chunks, nil
+	}
+
+	encoding := compressionEncoding(options)
+	prepared := make([]payloadChunk, 0, len(chunks))
+
+	for len(chunks) > 0 {
+		chunk := chunks[0]
+		chunks = chunks[1:]
+
+		compressed, err := compressBytes(chunk.body, encoding, options.CompressionLevel)
+		if err != nil {
+			return nil, err
+		}
+
+		if limit == 0 || len(compressed) <= limit {
+			prepared = append(prepared, payloadChunk{body: compressed, firstLine: chunk.firstLine, lastLine: chunk.lastLine})
+			continue
+		}
+
+		// Compression made the chunk larger than the limit, so halve it and try again
+		first, second, ok := halveChunk(chunk)
+		if !ok || options.Format == "json" {
+			return nil, fmt.Errorf("compressed payload for lines %d-%d is %d bytes, which exceeds the maximum payload size of %d bytes", chunk.firstLine, chunk.lastLine, len(compressed), limit)
+		}
+		chunks = append([]payloadChunk{first, second}, chunks...)
+	}
+
+	return prepared, nil
+}
+
+// splitLines splits NDJSON data into chunks of at most limit bytes without breaking lines.
+// firstLine is the line number of the first line in data.
+func splitLines(data []byte, firstLine int, limit int) ([]payloadChunk, error) {
+	var chunks []payloadChunk
+
+	start := 0
+	chunkFirstLine := firstLine
+	line := firstLine
+
+	for offset := 0; offset < len(data); line++ {
+		end := len(data)
+		if i := bytes.IndexByte(data[offset:], '\n'); i >= 0 {
+			end = offset + i + 1
+		}
+
+		if end-offset > limit {
+			return nil, fmt.Errorf("line %d is %d bytes, which exceeds the maximum payload size of %d bytes", line, end-offset, limit)
+		}
+
+		if end-start > limit {
+			chunks = append(chunks, payloadChunk{body: data[start:offset], firstLine: chunkFirstLine, lastLine: line - 1})
+			start = offset
+			chunkFirstLine = line
+		}
+
+		offset = end
+	}
+
+	if start < len(data) {
+		chunks = append(chunks, payloadChunk{body: data[start:], firstLine: chunkFirstLine, lastLine: line - 1})
+	}
+
+	return chunks, nil
+}
+
+// halveChunk splits a chunk into two on the line boundary closest to its middle.
+// It reports false if the chunk holds a single line.
+func halveChunk(chunk payloadChunk) (payloadChunk, payloadChunk, bool) {
+	body := chunk.body
+	mid := len(body) / 2
+
+	cut := -1
+	if i := bytes.IndexByte(body[mid:], '\n'); i >= 0 && mid+i+1 < len(body) {
+		cut = mid + i + 1
+	} else if i := bytes.LastIndexByte(body[:mid], '\n'); i >= 0 {
+		cut = i + 1
+	}
+
+	if cut <= 0 {
+		return payloadChunk{}, payloadChunk{}, false
+	}
+
+	split := chunk.firstLine + bytes.Count(body[:cut], []byte("\n"))
+	first := payloadChunk{body: body[:cut], firstLine: chunk.firstLine, lastLine: split - 1}
+	second := payloadChunk{body: body[cut:], firstLine: split, lastLine: chunk.lastLine}
+
+	return first, second, true
+}
+
+// countLines returns the number of NDJSON lines in data
+func countLines(data []byte) int {
+	lines := bytes.Count(data, []byte("\n"))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
+
+// sendChunks sends several chunks, sequentially or concurrently depending on the options,
+// and aggregates their row counts into a single WriteResponse.
+func (c *ClientImpl) sendChunks(ctx context.Context, datasourceName string, chunks []payloadChunk, options *SendEventsOptions) (*WriteResponse, error) {
+	concurrency := options.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	responses := make([]*WriteResponse, len(chunks))
+	errs := make([]error, len(chunks))
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, chunk := range chunks {
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, chunk payloadChunk) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			responses[i], errs[i] = c.sendChunk(ctx, datasourceName, chunk, options)
+		}(i, chunk)
+	}
+
+	wg.Wait()
+
+	var response WriteResponse
+	var sendErr SendEventsError
+
+	for i, chunk := range chunks {
+		if errs[i] != nil {
+			sendErr.Chunks = append(sendErr.Chunks, ChunkError{
+				Index:     i,
+				FirstLine: chunk.firstLine,
+				LastLine:  chunk.lastLine,
+				Err:       errs[i],
+			})
+			continue
+		}
+		response.SuccessfulRows += responses[i].SuccessfulRows
+		response.QuarantinedRows += responses[i].QuarantinedRows
+	}
+
+	if len(sendErr.Chunks) > 0 {
+		sendErr.TotalChunks = len(chunks)
+		return &response, &sendErr
+	}
+
+	return &response, nil
+}
@@ -0,0 +1,179 @@
+package tinybird
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSplitLines(t *testing.T) {
+	data := []byte("{\"a\":1}\n{\"a\":2}\n{\"a\":3}\n{\"a\":4}\n{\"a\":5}")
+
+	chunks, err := splitLines(data, 1, 16)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []payloadChunk{
+		{body: []byte("{\"a\":1}\n{\"a\":2}\n"), firstLine: 1, lastLine: 2},
+		{body: []byte("{\"a\":3}\n{\"a\":4}\n"), firstLine: 3, lastLine: 4},
+		{body: []byte("{\"a\":5}"), firstLine: 5, lastLine: 5},
+	}
+
+	if len(chunks) != len(expected) {
+		t.Fatalf("got %d chunks, want %d", len(chunks), len(expected))
+	}
+
+	for i, chunk := range chunks {
+		if !bytes.Equal(chunk.body, expected[i].body) {
+			t.Errorf("chunk %d body = %q, want %q", i, chunk.body, expected[i].body)
+		}
+		if chunk.firstLine != expected[i].firstLine || chunk.lastLine != expected[i].lastLine {
+			t.Errorf("chunk %d lines = %d-%d, want %d-%d", i, chunk.firstLine, chunk.lastLine, expected[i].firstLine, expected[i].lastLine)
+		}
+	}
+}
+
+func TestSplitLines_LineTooLarge(t *testing.T) {
+	data := []byte("{\"a\":1}\n{\"a\":\"this line is too long\"}\n")
+
+	_, err := splitLines(data, 1, 16)
+	if err == nil {
+		t.Fatal("expected error for oversized line, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("error = %q, want it to mention line 2", err.Error())
+	}
+}
+
+func TestHalveChunk(t *testing.T) {
+	chunk := payloadChunk{body: []byte("aaaa\nbbbb\ncccc\ndddd\n"), firstLine: 10, lastLine: 13}
+
+	first, second, ok := halveChunk(chunk)
+	if !ok {
+		t.Fatal("expected chunk to be split")
+	}
+
+	if string(first.body)+string(second.body) != string(chunk.body) {
+		t.Errorf("halves do not add up to the original chunk")
+	}
+	if first.firstLine != 10 || second.lastLine != 13 || first.lastLine+1 != second.firstLine {
+		t.Errorf("line ranges = %d-%d and %d-%d", first.firstLine, first.lastLine, second.firstLine, second.lastLine)
+	}
+
+	if _, _, ok := halveChunk(payloadChunk{body: []byte("single line\n"), firstLine: 1, lastLine: 1}); ok {
+		t.Error("expected a single line chunk not to be split")
+	}
+}
+
+func TestSendEvents_SplitsLargePayload(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	data := []byte("{\"a\":1}\n{\"a\":2}\n{\"a\":3}\n{\"a\":4}\n")
+	expectedURL := "https://api.tinybird.co/v0/events?name=events"
+
+	mockClient.On("PostRaw",
+		mock.Anything,
+		expectedURL,
+		mock.MatchedBy(func(body []byte) bool {
+			return len(body) <= 16
+		}),
+		"application/x-ndjson",
+		"",
+		mock.AnythingOfType("*tinybird.WriteResponse"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		response := args.Get(5).(*WriteResponse)
+		response.SuccessfulRows = bytes.Count(args.Get(2).([]byte), []byte("\n"))
+	}).Times(2)
+
+	response, err := client.SendEvents(context.Background(), "events", data, &SendEventsOptions{
+		MaxPayloadSize: 16,
+		Concurrency:    2,
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if response.SuccessfulRows != 4 {
+		t.Errorf("SuccessfulRows = %d, want 4", response.SuccessfulRows)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestSendEvents_ReportsFailedChunks(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	data := []byte("{\"a\":1}\n{\"a\":2}\n{\"a\":3}\n")
+	expectedErr := errors.New("HTTP 500: Internal Server Error")
+
+	mockClient.On("PostRaw",
+		mock.Anything,
+		mock.Anything,
+		[]byte("{\"a\":2}\n"),
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+	).Return(expectedErr)
+
+	mockClient.On("PostRaw",
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+	).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(5).(*WriteResponse).SuccessfulRows = 1
+	})
+
+	response, err := client.SendEvents(context.Background(), "events", data, &SendEventsOptions{
+		MaxPayloadSize: 8,
+	})
+
+	var sendErr *SendEventsError
+	if !errors.As(err, &sendErr) {
+		t.Fatalf("error = %v, want *SendEventsError", err)
+	}
+
+	if sendErr.TotalChunks != 3 || len(sendErr.Chunks) != 1 {
+		t.Fatalf("failed chunks = %d of %d, want 1 of 3", len(sendErr.Chunks), sendErr.TotalChunks)
+	}
+
+	failed := sendErr.Chunks[0]
+	if failed.Index != 1 || failed.FirstLine != 2 || failed.LastLine != 2 {
+		t.Errorf("failed chunk = %+v, want index 1 covering line 2", failed)
+	}
+
+	if !errors.Is(err, expectedErr) {
+		t.Errorf("error does not wrap %v", expectedErr)
+	}
+
+	if response == nil || response.SuccessfulRows != 2 {
+		t.Errorf("response = %+v, want 2 successful rows", response)
+	}
+}
+
+func TestSendEvents_JSONFormatTooLarge(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	_, err := client.SendEvents(context.Background(), "events", []byte(`{"name":"too large"}`), &SendEventsOptions{
+		Format:         "json",
+		MaxPayloadSize: 8,
+	})
+
+	if err == nil {
+		t.Fatal("expected error for oversized json payload, got nil")
+	}
+
+	mockClient.AssertNotCalled(t, "PostRaw")
+}
@@ -0,0 +1,182 @@
+package tinybird
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+func (c *ClientImpl) SendEventsStream(ctx context.Context, datasourceName string, data io.Reader, options *SendEventsOptions) (*WriteResponse, error) {
+	if options == nil {
+		options = &SendEventsOptions{}
+	}
+
+	contentEncoding := ""
+	if options.Compress {
+		contentEncoding = compressionEncoding(options)
+
+		// Fail early on an unsupported encoding or level rather than on the first attempt
+		_, release, err := acquireEncoder(contentEncoding, options.CompressionLevel, io.Discard)
+		if err != nil {
+			return nil, err
+		}
+		release()
+	}
+
+	source, err := newReplayableSource(data)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	getBody := func() (io.ReadCloser, error) {
+		r, err := source.open()
+		if err != nil {
+			return nil, err
+		}
+
+		if contentEncoding == "" {
+			body := &trackedBody{Reader: r, done: make(chan struct{})}
+			source.track(body.done)
+			return body, nil
+		}
+
+		body, done, err := compressStream(r, contentEncoding, options.CompressionLevel)
+		if err != nil {
+			return nil, err
+		}
+		source.track(done)
+
+		return body, nil
+	}
+
+	var response WriteResponse
+
+	err = c.httpClient.PostStream(ctx, c.eventsURL(datasourceName, options), getBody, eventsContentType(options), contentEncoding, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// replayableSource lets a request body be read from the start once per retry attempt.
+//
+// Seekable readers are rewound to their initial offset. Other readers are
+// spooled to a temporary file as they are consumed, so a later attempt replays
+// the spooled bytes and then continues reading from the original reader.
+type replayableSource struct {
+	seeker io.ReadSeeker
+	start  int64
+
+	src     io.Reader
+	spool   *os.File
+	written int64
+	eof     bool
+
+	mu      sync.Mutex
+	pending <-chan struct{}
+}
+
+func newReplayableSource(r io.Reader) (*replayableSource, error) {
+	if seeker, ok := r.(io.ReadSeeker); ok {
+		// Pipes and terminals implement io.Seeker but fail when seeking
+		if start, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			return &replayableSource{seeker: seeker, start: start}, nil
+		}
+	}
+
+	return &replayableSource{src: r}, nil
+}
+
+// open returns a reader positioned at the start of the payload
+func (s *replayableSource) open() (io.Reader, error) {
+	// Wait for the previous attempt to stop reading before rewinding
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	if pending != nil {
+		<-pending
+	}
+
+	if s.seeker != nil {
+		if _, err := s.seeker.Seek(s.start, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind event stream: %w", err)
+		}
+		return s.seeker, nil
+	}
+
+	if s.spool == nil {
+		spool, err := os.CreateTemp("", "tinybird-events-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create spool file: %w", err)
+		}
+		s.spool = spool
+	}
+
+	replay := io.NewSectionReader(s.spool, 0, s.written)
+	if s.eof {
+		return replay, nil
+	}
+
+	return io.MultiReader(replay, spoolingReader{s}), nil
+}
+
+// track registers a channel that is closed once the current attempt is done reading
+func (s *replayableSource) track(done <-chan struct{}) {
+	s.mu.Lock()
+	s.pending = done
+	s.mu.Unlock()
+}
+
+// Close waits for any in-flight attempt and removes the spool file
+func (s *replayableSource) Close() error {
+	s.mu.Lock()
+	pending := s.pending
+	s.mu.Unlock()
+	if pending != nil {
+		<-pending
+	}
+
+	if s.spool == nil {
+		return nil
+	}
+
+	name := s.spool.Name()
+	s.spool.Close()
+	return os.Remove(name)
+}
+
+// spoolingReader reads from the original source and appends everything it reads to the spool
+type spoolingReader struct {
+	s *replayableSource
+}
+
+func (r spoolingReader) Read(p []byte) (int, error) {
+	n, err := r.s.src.Read(p)
+	if n > 0 {
+		if _, werr := r.s.spool.WriteAt(p[:n], r.s.written); werr != nil {
+			return 0, fmt.Errorf("failed to spool event stream: %w", werr)
+		}
+		r.s.written += int64(n)
+	}
+	if err == io.EOF {
+		r.s.eof = true
+	}
+	return n, err
+}
+
+// trackedBody signals when the transport has finished with an uncompressed request body
+type trackedBody struct {
+	io.Reader
+	once sync.Once
+	done chan struct{}
+}
+
+func (b *trackedBody) Close() error {
+	b.once.Do(func() { close(b.done) })
+	return nil
+}
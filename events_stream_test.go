@@ -0,0 +1,174 @@
+package tinybird
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/mock"
+)
+
+// captureBody matches any body function and stores it for later inspection
+func captureBody(getBody *func() (io.ReadCloser, error)) interface{} {
+	return mock.MatchedBy(func(fn func() (io.ReadCloser, error)) bool {
+		*getBody = fn
+		return true
+	})
+}
+
+func readBody(t *testing.T, getBody func() (io.ReadCloser, error)) []byte {
+	t.Helper()
+
+	body, err := getBody()
+	if err != nil {
+		t.Fatalf("failed to open body: %v", err)
+	}
+	defer body.Close()
+
+	payload, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	return payload
+}
+
+func TestSendEventsStream_GzipReplaysSeekableReader(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	data := []byte("{\"event\":\"a\"}\n{\"event\":\"b\"}\n")
+	expectedURL := "https://api.tinybird.co/v0/events?name=stream_ds"
+
+	var getBody func() (io.ReadCloser, error)
+	mockClient.On("PostStream",
+		mock.Anything,
+		expectedURL,
+		captureBody(&getBody),
+		"application/x-ndjson",
+		"gzip",
+		mock.AnythingOfType("*tinybird.WriteResponse"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		// Simulate a retry: the body must be replayable from the start
+		for attempt := 0; attempt < 2; attempt++ {
+			reader, err := gzip.NewReader(bytes.NewReader(readBody(t, getBody)))
+			if err != nil {
+				t.Fatalf("attempt %d: failed to create gzip reader: %v", attempt, err)
+			}
+			decompressed, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("attempt %d: failed to decompress: %v", attempt, err)
+			}
+			if !bytes.Equal(decompressed, data) {
+				t.Errorf("attempt %d: decompressed body = %q, want %q", attempt, decompressed, data)
+			}
+		}
+	})
+
+	_, err := client.SendEventsStream(context.Background(), "stream_ds", bytes.NewReader(data), &SendEventsOptions{
+		Compress: true,
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestSendEventsStream_SpoolsNonSeekableReader(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	data := strings.Repeat("{\"event\":\"spooled\"}\n", 1000)
+
+	var getBody func() (io.ReadCloser, error)
+	mockClient.On("PostStream",
+		mock.Anything,
+		mock.Anything,
+		captureBody(&getBody),
+		"application/x-ndjson",
+		"zstd",
+		mock.Anything,
+	).Return(nil).Run(func(args mock.Arguments) {
+		// First attempt fails part way through the body
+		body, err := getBody()
+		if err != nil {
+			t.Fatalf("failed to open body: %v", err)
+		}
+		if _, err := io.ReadFull(body, make([]byte, 16)); err != nil {
+			t.Fatalf("failed to read partial body: %v", err)
+		}
+		body.Close()
+
+		// Second attempt must see the complete payload
+		decoder, err := zstd.NewReader(nil)
+		if err != nil {
+			t.Fatalf("failed to create zstd decoder: %v", err)
+		}
+		defer decoder.Close()
+
+		decompressed, err := decoder.DecodeAll(readBody(t, getBody), nil)
+		if err != nil {
+			t.Fatalf("failed to decompress zstd: %v", err)
+		}
+		if string(decompressed) != data {
+			t.Errorf("decompressed body length = %d, want %d", len(decompressed), len(data))
+		}
+	})
+
+	// io.MultiReader hides the underlying reader's Seek method
+	reader := io.MultiReader(strings.NewReader(data))
+
+	_, err := client.SendEventsStream(context.Background(), "stream_ds", reader, &SendEventsOptions{
+		Compress:            true,
+		CompressionEncoding: "zstd",
+		CompressionLevel:    3,
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestSendEventsStream_UnsupportedCompression(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	_, err := client.SendEventsStream(context.Background(), "datasource", strings.NewReader(`{}`), &SendEventsOptions{
+		Compress:            true,
+		CompressionEncoding: "lz4",
+	})
+
+	if err == nil {
+		t.Fatal("expected error for unsupported compression, got nil")
+	}
+
+	expectedErr := "unsupported compression encoding: lz4"
+	if err.Error() != expectedErr {
+		t.Errorf("error = %q, want %q", err.Error(), expectedErr)
+	}
+
+	mockClient.AssertNotCalled(t, "PostStream")
+}
+
+func TestSendEvents_InvalidGzipLevel(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	_, err := client.SendEvents(context.Background(), "datasource", []byte(`{}`), &SendEventsOptions{
+		Compress:         true,
+		CompressionLevel: 42,
+	})
+
+	if err == nil {
+		t.Fatal("expected error for invalid compression level, got nil")
+	}
+
+	mockClient.AssertNotCalled(t, "PostRaw")
+}
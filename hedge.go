@@ -0,0 +1,176 @@
+package tinybird
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHedgingMinSamples is the number of latencies observed per endpoint before a percentile delay is used.
+	DefaultHedgingMinSamples = 20
+
+	// hedgingLatencyWindow is the number of recent latencies kept per endpoint
+	hedgingLatencyWindow = 256
+)
+
+// hedger sends backup requests for slow reads, returning whichever response arrives first
+type hedger struct {
+	delay      time.Duration
+	percentile float64
+	minSamples int
+	maxHedges  int
+	onHedge    func(name string)
+
+	mu        sync.Mutex
+	latencies map[string]*latencyWindow
+}
+
+// latencyWindow is a ring buffer of recent request latencies
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+}
+
+type hedgeResult struct {
+	response *EndpointResponse
+	err      error
+	latency  time.Duration
+}
+
+func newHedger(options *HedgingOptions) *hedger {
+	minSamples := options.MinSamples
+	if minSamples <= 0 {
+		minSamples = DefaultHedgingMinSamples
+	}
+
+	maxHedges := options.MaxHedges
+	if maxHedges <= 0 {
+		maxHedges = 1
+	}
+
+	return &hedger{
+		delay:      options.Delay,
+		percentile: options.Percentile,
+		minSamples: minSamples,
+		maxHedges:  maxHedges,
+		onHedge:    options.OnHedge,
+		latencies:  map[string]*latencyWindow{},
+	}
+}
+
+// do calls fetch and, if it has not completed after the hedging delay, calls it
+// again, up to maxHedges extra times. The first successful response wins and the
+// other requests are cancelled. name groups latencies, such as an endpoint name.
+func (h *hedger) do(ctx context.Context, name string, fetch func(context.Context) (*EndpointResponse, error)) (*EndpointResponse, error) {
+	delay := h.delayFor(name)
+	if delay <= 0 {
+		return h.measure(ctx, name, fetch)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeResult, h.maxHedges+1)
+	launch := func() {
+		go func() {
+			start := time.Now()
+			response, err := fetch(ctx)
+			results <- hedgeResult{response: response, err: err, latency: time.Since(start)}
+		}()
+	}
+
+	launch()
+	sent, inflight := 1, 1
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case result := <-results:
+			inflight--
+			if result.err == nil {
+				h.record(name, result.latency)
+				return result.response, nil
+			}
+			lastErr = result.err
+			if inflight == 0 {
+				// Failures are not hedged: retries are the HTTP client's job
+				return nil, lastErr
+			}
+		case <-timer.C:
+			if sent <= h.maxHedges {
+				if h.onHedge != nil {
+					h.onHedge(name)
+				}
+				launch()
+				sent++
+				inflight++
+				timer.Reset(delay)
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// measure calls fetch without hedging, recording its latency for later percentile delays
+func (h *hedger) measure(ctx context.Context, name string, fetch func(context.Context) (*EndpointResponse, error)) (*EndpointResponse, error) {
+	start := time.Now()
+
+	response, err := fetch(ctx)
+	if err == nil {
+		h.record(name, time.Since(start))
+	}
+
+	return response, err
+}
+
+// delayFor returns how long to wait before hedging a request, 0 to not hedge it
+func (h *hedger) delayFor(name string) time.Duration {
+	if h.percentile <= 0 {
+		return h.delay
+	}
+
+	h.mu.Lock()
+	window := h.latencies[name]
+	var samples []time.Duration
+	if window != nil {
+		samples = slices.Clone(window.samples)
+	}
+	h.mu.Unlock()
+
+	// Until enough latencies are known, fall back to the fixed delay
+	if len(samples) < h.minSamples {
+		return h.delay
+	}
+
+	slices.Sort(samples)
+	index := int(h.percentile * float64(len(samples)-1))
+	return samples[min(index, len(samples)-1)]
+}
+
+func (h *hedger) record(name string, latency time.Duration) {
+	if h.percentile <= 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	window, ok := h.latencies[name]
+	if !ok {
+		window = &latencyWindow{}
+		h.latencies[name] = window
+	}
+
+	if len(window.samples) < hedgingLatencyWindow {
+		window.samples = append(window.samples, latency)
+		return
+	}
+	window.samples[window.next] = latency
+	window.next = (window.next + 1) % hedgingLatencyWindow
+}
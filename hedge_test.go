@@ -0,0 +1,106 @@
+package tinybird
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCallEndpoint_HedgeWinsOverSlowRequest(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	var hedges atomic.Int32
+	client.hedger = newHedger(&HedgingOptions{
+		Delay:   10 * time.Millisecond,
+		OnHedge: func(name string) { hedges.Add(1) },
+	})
+
+	var calls atomic.Int32
+	loserCancelled := make(chan struct{})
+	mockClient.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		if calls.Add(1) == 1 {
+			// The first request hangs until it is cancelled
+			<-args.Get(0).(context.Context).Done()
+			close(loserCancelled)
+			return
+		}
+		args.Get(3).(*EndpointResponse).Rows = 2
+	})
+
+	response, err := client.CallEndpoint(context.Background(), "top_pages", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Rows != 2 {
+		t.Errorf("Rows = %d, want the hedged response", response.Rows)
+	}
+	if hedges.Load() != 1 {
+		t.Errorf("hedges = %d, want 1", hedges.Load())
+	}
+
+	select {
+	case <-loserCancelled:
+	case <-time.After(time.Second):
+		t.Fatal("slow request was not cancelled")
+	}
+}
+
+func TestQuery_NoHedgeForFastRequest(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+	client.hedger = newHedger(&HedgingOptions{Delay: time.Second})
+
+	mockClient.On("Get", mock.Anything, "https://api.tinybird.co/v0/sql", mock.Anything, mock.Anything).Return(nil)
+
+	if _, err := client.Query(context.Background(), "SELECT 1", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient.AssertNumberOfCalls(t, "Get", 1)
+}
+
+func TestHedger_ErrorsAreNotHedged(t *testing.T) {
+	h := newHedger(&HedgingOptions{Delay: time.Second})
+
+	var calls atomic.Int32
+	_, err := h.do(context.Background(), "top_pages", func(ctx context.Context) (*EndpointResponse, error) {
+		calls.Add(1)
+		return nil, errors.New("bad request")
+	})
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestHedger_PercentileDelay(t *testing.T) {
+	h := newHedger(&HedgingOptions{
+		Delay:      time.Second,
+		Percentile: 0.9,
+		MinSamples: 10,
+	})
+
+	for i := 1; i <= 9; i++ {
+		h.record("top_pages", time.Duration(i)*time.Millisecond)
+	}
+	if got := h.delayFor("top_pages"); got != time.Second {
+		t.Errorf("delay = %v with too few samples, want the fixed delay", got)
+	}
+
+	h.record("top_pages", 10*time.Millisecond)
+	if got := h.delayFor("top_pages"); got != 9*time.Millisecond {
+		t.Errorf("delay = %v, want p90 of 9ms", got)
+	}
+
+	if got := h.delayFor("other"); got != time.Second {
+		t.Errorf("delay = %v for an endpoint without samples, want the fixed delay", got)
+	}
+}
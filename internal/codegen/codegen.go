@@ -0,0 +1,479 @@
+// Package codegen generates typed Go code for the datasources and endpoints of
+// a Tinybird project parsed with the datafile package.
+//
+// For each datasource it generates a row struct and a function sending rows
+// through Client.SendEvents. For each pipe with an endpoint node it generates
+// a params struct, a result row struct and a function wrapping
+// Client.CallEndpoint. Output is sorted and gofmt-ed so that regenerating an
+// unchanged project produces an identical file.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+
+	"github.com/NOLLYWOOD-COM/tinybird/internal/datafile"
+)
+
+// Import paths of the packages the generated code uses besides the standard library
+const (
+	clientImportPath  = "github.com/NOLLYWOOD-COM/tinybird"
+	chtypesImportPath = "github.com/NOLLYWOOD-COM/tinybird/chtypes"
+)
+
+// qualifiedImports maps the package qualifiers of field types to their import paths
+var qualifiedImports = map[string]string{
+	"big.":     "math/big",
+	"chtypes.": chtypesImportPath,
+	"json.":    "encoding/json",
+	"netip.":   "net/netip",
+	"time.":    "time",
+}
+
+// Column is a named column with a ClickHouse type. Its JSON form matches the
+// meta entries of Tinybird responses.
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Project is the set of datafiles to generate code for
+type Project struct {
+	Datasources []*datafile.Datasource
+	Pipes       []*datafile.Pipe
+}
+
+// Config controls code generation
+type Config struct {
+	Package string // Package name of the generated file
+
+	// Meta overrides the result columns of endpoints, by pipe name. Columns
+	// are otherwise inferred from the SQL of the endpoint node, which may
+	// leave some types as interface{} or the whole row as a map.
+	Meta map[string][]Column
+}
+
+// Generate returns the gofmt-ed Go source for project
+func Generate(project *Project, config Config) ([]byte, error) {
+	if config.Package == "" {
+		return nil, fmt.Errorf("package name is required")
+	}
+
+	datasources := append([]*datafile.Datasource(nil), project.Datasources...)
+	sort.Slice(datasources, func(i, j int) bool { return datasources[i].Name < datasources[j].Name })
+
+	pipes := append([]*datafile.Pipe(nil), project.Pipes...)
+	sort.Slice(pipes, func(i, j int) bool { return pipes[i].Name < pipes[j].Name })
+
+	g := &generator{
+		resolver: &resolver{
+			datasources: make(map[string]*datafile.Datasource, len(datasources)),
+			pipes:       make(map[string]*datafile.Pipe, len(pipes)),
+		},
+		declared: map[string]string{},
+		uses:     map[string]bool{},
+	}
+	for _, datasource := range datasources {
+		g.resolver.datasources[datasource.Name] = datasource
+	}
+	for _, pipe := range pipes {
+		g.resolver.pipes[pipe.Name] = pipe
+	}
+
+	for _, datasource := range datasources {
+		if err := g.datasource(datasource); err != nil {
+			return nil, err
+		}
+	}
+	for _, pipe := range pipes {
+		endpoint := pipe.Endpoint()
+		if endpoint == nil {
+			continue
+		}
+		if err := g.endpoint(pipe, endpoint, config.Meta[pipe.Name]); err != nil {
+			return nil, err
+		}
+	}
+	if g.needsRowValue {
+		g.rowValue()
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by tinybird-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", config.Package)
+	g.imports(&out)
+	out.Write(g.body.Bytes())
+
+	source, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid Go code: %w", err)
+	}
+	return source, nil
+}
+
+// Struct returns a gofmt-ed Go file in package pkg declaring a struct named name
+// for rows with the given columns, with a MarshalJSON method when JSONPaths nest
+// fields, and the imports both need. It fails for JSONPaths a row struct cannot
+// represent. doc is added to the type's doc comment.
+func Struct(pkg string, name string, doc string, columns []datafile.Column) ([]byte, error) {
+	if pkg == "" {
+		return nil, fmt.Errorf("package name is required")
+	}
+
+	g := &generator{declared: map[string]string{}, uses: map[string]bool{}}
+	if err := g.rowStruct(name, doc, columns); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	g.imports(&out)
+	out.Write(g.body.Bytes())
+
+	source, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid Go code: %w", err)
+	}
+	return source, nil
+}
+
+type generator struct {
+	resolver      *resolver
+	body          bytes.Buffer
+	declared      map[string]string // Go identifiers and the datafile that declared them
+	uses          map[string]bool   // Import paths used by the body
+	needsRowValue bool
+}
+
+// use records that the body refers to the packages at paths
+func (g *generator) use(paths ...string) {
+	for _, path := range paths {
+		g.uses[path] = true
+	}
+}
+
+// declare records a top-level identifier, failing when two datafiles map to the same name
+func (g *generator) declare(identifier string, source string) error {
+	if other, ok := g.declared[identifier]; ok {
+		return fmt.Errorf("%s and %s both generate %s", other, source, identifier)
+	}
+	g.declared[identifier] = source
+	return nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.body, format, args...)
+}
+
+func (g *generator) datasource(datasource *datafile.Datasource) error {
+	name := GoName(datasource.Name)
+	rowType := name + "Row"
+	sendFunc := "Send" + name
+	for _, identifier := range []string{rowType, sendFunc} {
+		if err := g.declare(identifier, datasource.Name+".datasource"); err != nil {
+			return err
+		}
+	}
+
+	doc := fmt.Sprintf("%s is a row of the %s datasource.", rowType, datasource.Name)
+	if datasource.Description != "" {
+		doc += "\n\n" + datasource.Description
+	}
+	if err := g.rowStruct(rowType, doc, datasource.Columns); err != nil {
+		return fmt.Errorf("datasource %s: %w", datasource.Name, err)
+	}
+
+	g.use("bytes", "context", "encoding/json", "fmt", clientImportPath)
+	g.printf("// %s sends rows to the %s datasource as NDJSON.\n", sendFunc, datasource.Name)
+	g.printf("func %s(ctx context.Context, client tinybird.Client, rows []%s, options *tinybird.SendEventsOptions, callOptions ...tinybird.CallOption) (*tinybird.WriteResponse, error) {\n", sendFunc, rowType)
+	g.printf("var buf bytes.Buffer\n")
+	g.printf("encoder := json.NewEncoder(&buf)\n")
+	g.printf("for i := range rows {\n")
+	g.printf("if err := encoder.Encode(rows[i]); err != nil {\n")
+	g.printf("return nil, fmt.Errorf(\"failed to encode %s row %%d: %%w\", i, err)\n", datasource.Name)
+	g.printf("}\n}\n")
+	g.printf("return client.SendEvents(ctx, %q, buf.Bytes(), options, callOptions...)\n", datasource.Name)
+	g.printf("}\n\n")
+
+	return nil
+}
+
+// rowStruct writes a struct for rows with the given columns and, when their
+// JSONPaths nest fields, a MarshalJSON method building the nested object
+func (g *generator) rowStruct(name string, doc string, columns []datafile.Column) error {
+	tree, err := jsonPathTree(columns)
+	if err != nil {
+		return err
+	}
+
+	fields := make([]structField, len(columns))
+	for i, column := range columns {
+		fields[i] = structField{
+			Name:    GoName(column.Name),
+			Type:    GoType(column.Type),
+			JSON:    column.Name,
+			Comment: strings.Join(nonEmpty(column.Type, column.JSONPath), ", "),
+		}
+	}
+
+	g.comment(doc)
+	g.structType(name, fields)
+
+	if tree != nil {
+		g.use("encoding/json")
+		g.printf("// MarshalJSON nests fields according to their JSONPaths.\n")
+		g.printf("func (r %s) MarshalJSON() ([]byte, error) {\n", name)
+		g.printf("return json.Marshal(")
+		g.jsonObject(tree)
+		g.printf(")\n}\n\n")
+	}
+
+	return nil
+}
+
+func (g *generator) endpoint(pipe *datafile.Pipe, node *datafile.Node, meta []Column) error {
+	name := GoName(pipe.Name)
+	paramsType := name + "Params"
+	rowType := name + "Row"
+	for _, identifier := range []string{paramsType, rowType, name} {
+		if err := g.declare(identifier, pipe.Name+".pipe"); err != nil {
+			return err
+		}
+	}
+
+	// Parameters can be used by any node feeding the endpoint
+	var sql strings.Builder
+	for _, n := range pipe.Nodes {
+		sql.WriteString(n.SQL)
+		sql.WriteByte('\n')
+	}
+	params := datafile.Params(sql.String())
+
+	fields := make([]structField, len(params))
+	for i, param := range params {
+		typ := ParamGoType(param.Type, param.ElementType)
+		comment := param.Type
+		if param.Type == "Array" {
+			comment = "Array of " + param.ElementType
+		}
+		if param.Required {
+			comment += ", required"
+		} else if param.Type != "Array" {
+			typ = "*" + typ
+		}
+		if param.Default != "" {
+			comment += ", default " + param.Default
+		}
+		if param.Description != "" {
+			comment += ". " + param.Description
+		}
+		fields[i] = structField{Name: GoName(param.Name), Type: typ, Comment: comment}
+	}
+
+	g.printf("// %s are the parameters of the %s endpoint. Optional parameters are\n", paramsType, pipe.Name)
+	g.printf("// pointers and are not sent when nil.\n")
+	g.structType(paramsType, fields)
+
+	g.printf("// Values returns the parameters as expected by Client.CallEndpoint.\n")
+	g.printf("func (p %s) Values() map[string]string {\n", paramsType)
+	g.printf("params := make(map[string]string, %d)\n", len(params))
+	for i, param := range params {
+		g.paramValue(param, fields[i])
+	}
+	g.printf("return params\n}\n\n")
+
+	columns := meta
+	if columns == nil {
+		columns = g.resolver.nodeColumns(pipe, node, 0)
+	}
+
+	if columns == nil {
+		g.printf("// %s is a row returned by the %s endpoint, whose columns could not be\n", rowType, pipe.Name)
+		g.printf("// inferred. Pass -meta to tinybird-gen to generate a struct.\n")
+		g.printf("type %s = map[string]interface{}\n\n", rowType)
+	} else {
+		rowFields := make([]structField, len(columns))
+		for i, column := range columns {
+			rowFields[i] = structField{Name: GoName(column.Name), Type: GoType(column.Type), JSON: column.Name, Comment: column.Type}
+		}
+		g.printf("// %s is a row returned by the %s endpoint.\n", rowType, pipe.Name)
+		g.structType(rowType, rowFields)
+	}
+
+	g.use("context", "fmt", clientImportPath)
+	doc := fmt.Sprintf("%s calls the %s endpoint.", name, pipe.Name)
+	if pipe.Description != "" {
+		doc += "\n\n" + pipe.Description
+	}
+	g.comment(doc)
+	g.printf("func %s(ctx context.Context, client tinybird.Client, params %s, callOptions ...tinybird.CallOption) ([]%s, error) {\n", name, paramsType, rowType)
+	g.printf("response, err := client.CallEndpoint(ctx, %q, params.Values(), callOptions...)\n", pipe.Name)
+	g.printf("if err != nil {\nreturn nil, err\n}\n")
+	if columns == nil {
+		g.printf("return response.DecodedRows()\n}\n\n")
+		return nil
+	}
+
+	g.use("errors")
+	g.printf("decoded, err := response.DecodedRows()\n")
+	g.printf("if err != nil {\nreturn nil, fmt.Errorf(\"failed to decode %s rows: %%w\", err)\n}\n", pipe.Name)
+	g.printf("rows := make([]%s, len(decoded))\n", rowType)
+	g.printf("for i, row := range decoded {\n")
+	g.printf("err := errors.Join(\n")
+	for _, column := range columns {
+		g.printf("rowValue(row, %q, &rows[i].%s),\n", column.Name, GoName(column.Name))
+	}
+	g.printf(")\n")
+	g.printf("if err != nil {\nreturn nil, fmt.Errorf(\"failed to decode %s row %%d: %%w\", i, err)\n}\n", pipe.Name)
+	g.printf("}\nreturn rows, nil\n}\n\n")
+	g.needsRowValue = true
+
+	return nil
+}
+
+// paramValue writes the statement adding a parameter to the params map
+func (g *generator) paramValue(param datafile.Param, field structField) {
+	value := "p." + field.Name
+	if !param.Required {
+		g.printf("if %s != nil {\n", value)
+		if param.Type != "Array" {
+			value = "*" + value
+		}
+	}
+
+	goType := strings.TrimPrefix(field.Type, "*")
+	if elementType, ok := strings.CutPrefix(goType, "[]"); ok {
+		g.use("strings")
+		g.printf("values := make([]string, len(%s))\n", value)
+		g.printf("for i, v := range %s {\nvalues[i] = %s\n}\n", value, g.formatValue("v", elementType))
+		g.printf("params[%q] = strings.Join(values, \",\")\n", param.Name)
+	} else {
+		g.printf("params[%q] = %s\n", param.Name, g.formatValue(value, goType))
+	}
+
+	if !param.Required {
+		g.printf("}\n")
+	}
+}
+
+// formatValue returns the expression formatting value of goType as a string
+func (g *generator) formatValue(value string, goType string) string {
+	if goType != "string" {
+		g.use("strconv")
+	}
+
+	switch goType {
+	case "bool":
+		return "strconv.FormatBool(" + value + ")"
+	case "int":
+		return "strconv.Itoa(" + value + ")"
+	case "int8", "int16", "int32", "int64":
+		return "strconv.FormatInt(int64(" + value + "), 10)"
+	case "uint8", "uint16", "uint32", "uint64":
+		return "strconv.FormatUint(uint64(" + value + "), 10)"
+	case "float32":
+		return "strconv.FormatFloat(float64(" + value + "), 'f', -1, 32)"
+	case "float64":
+		return "strconv.FormatFloat(" + value + ", 'f', -1, 64)"
+	default:
+		return value
+	}
+}
+
+// rowValue writes the helper copying a decoded column into a typed row field
+func (g *generator) rowValue() {
+	g.use("fmt")
+	g.printf("// rowValue sets *field to the decoded value of column, failing when the value\n")
+	g.printf("// does not have the type of the field. Missing columns leave the field unset.\n")
+	g.printf("func rowValue[T any](row map[string]interface{}, column string, field *T) error {\n")
+	g.printf("value, ok := row[column]\n")
+	g.printf("if !ok || value == nil {\nreturn nil\n}\n")
+	g.printf("typed, ok := value.(T)\n")
+	g.printf("if !ok {\nreturn fmt.Errorf(\"column %%s: got %%T, want %%T\", column, value, *field)\n}\n")
+	g.printf("*field = typed\nreturn nil\n}\n")
+}
+
+type structField struct {
+	Name    string
+	Type    string
+	JSON    string // JSON key, no tag when empty
+	Comment string
+}
+
+func (g *generator) structType(name string, fields []structField) {
+	g.printf("type %s struct {\n", name)
+	for _, field := range fields {
+		for qualifier, path := range qualifiedImports {
+			if strings.Contains(field.Type, qualifier) {
+				g.use(path)
+			}
+		}
+		g.printf("%s %s", field.Name, field.Type)
+		if field.JSON != "" {
+			g.printf(" `json:%q`", field.JSON)
+		}
+		if field.Comment != "" {
+			g.printf(" // %s", oneLine(field.Comment))
+		}
+		g.printf("\n")
+	}
+	g.printf("}\n\n")
+}
+
+// comment writes text as a doc comment
+func (g *generator) comment(text string) {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			g.printf("//\n")
+		} else {
+			g.printf("// %s\n", line)
+		}
+	}
+}
+
+// imports writes the import block for the packages the body uses
+func (g *generator) imports(out *bytes.Buffer) {
+	if len(g.uses) == 0 {
+		return
+	}
+
+	var std, module []string
+	for path := range g.uses {
+		if strings.HasPrefix(path, clientImportPath) {
+			module = append(module, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(module)
+
+	out.WriteString("import (\n")
+	for _, path := range std {
+		fmt.Fprintf(out, "%q\n", path)
+	}
+	if len(module) > 0 {
+		out.WriteString("\n")
+	}
+	for _, path := range module {
+		fmt.Fprintf(out, "%q\n", path)
+	}
+	out.WriteString(")\n\n")
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,187 @@
+package codegen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/NOLLYWOOD-COM/tinybird/internal/datafile"
+)
+
+func testProject(t *testing.T) *Project {
+	t.Helper()
+
+	datasource, err := datafile.ParseDatasource("page_views", strings.NewReader(`SCHEMA >
+    `+"`timestamp` DateTime `json:$.timestamp`"+`,
+    `+"`session_id` String `json:$.session.id`"+`,
+    `+"`country` LowCardinality(Nullable(String)) `json:$.country`"+`
+`))
+	if err != nil {
+		t.Fatalf("ParseDatasource() error: %v", err)
+	}
+
+	pipe, err := datafile.ParsePipe("top_pages", strings.NewReader(`NODE filtered
+SQL >
+    %
+    SELECT * FROM page_views WHERE country = {{ String(country, required=True) }}
+
+NODE endpoint
+SQL >
+    %
+    SELECT session_id, count() AS views, max(timestamp) AS last_seen, sum(x) AS total
+    FROM filtered
+    LIMIT {{ Int32(limit, 10) }}
+TYPE endpoint
+`))
+	if err != nil {
+		t.Fatalf("ParsePipe() error: %v", err)
+	}
+
+	return &Project{Datasources: []*datafile.Datasource{datasource}, Pipes: []*datafile.Pipe{pipe}}
+}
+
+func TestGoType(t *testing.T) {
+	tests := map[string]string{
+		"String":                           "string",
+		"LowCardinality(Nullable(String))": "*string",
+		"Array(Nullable(Int32))":           "[]*int32",
+		"Map(String, UInt64)":              "map[string]uint64",
+		"DateTime64(3, 'UTC')":             "time.Time",
+		"Decimal(18, 4)":                   "chtypes.Decimal",
+		"UInt256":                          "*big.Int",
+		"Tuple(String, Int8)":              "[]interface{}",
+		"Bool":                             "bool",
+		"AggregateFunction(uniq, String)":  "interface{}",
+	}
+	for chType, expected := range tests {
+		if got := GoType(chType); got != expected {
+			t.Errorf("GoType(%q) = %q, want %q", chType, got, expected)
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"user_id":     "UserID",
+		"top-pages":   "TopPages",
+		"api_url":     "APIURL",
+		"2024_report": "X2024Report",
+		"__":          "Field",
+	}
+	for name, expected := range tests {
+		if got := GoName(name); got != expected {
+			t.Errorf("GoName(%q) = %q, want %q", name, got, expected)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	source, err := Generate(testProject(t), Config{Package: "tbgen"})
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	// Compare with collapsed whitespace, ignoring gofmt alignment
+	code := strings.Join(strings.Fields(string(source)), " ")
+
+	for _, expected := range []string{
+		"// Code generated by tinybird-gen. DO NOT EDIT.",
+		"package tbgen",
+		"type PageViewsRow struct",
+		`"session": map[string]interface{}{`,
+		"func SendPageViews(ctx context.Context, client tinybird.Client, rows []PageViewsRow",
+		"Country string",
+		"Limit *int32",
+		`params["country"] = p.Country`,
+		"Views uint64",
+		"LastSeen time.Time",
+		"Total interface{}",
+		`rowValue(row, "last_seen", &rows[i].LastSeen),`,
+		"func TopPages(ctx context.Context, client tinybird.Client, params TopPagesParams",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("generated code does not contain %q:\n%s", expected, source)
+		}
+	}
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	project := testProject(t)
+	first, err := Generate(project, Config{Package: "tbgen"})
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	// Reversed input order must not change the output
+	project.Datasources = append(project.Datasources, &datafile.Datasource{
+		Name:    "a_first",
+		Columns: []datafile.Column{{Name: "id", Type: "UInt64"}},
+	})
+	project.Datasources[0], project.Datasources[1] = project.Datasources[1], project.Datasources[0]
+
+	for i := 0; i < 5; i++ {
+		again, err := Generate(project, Config{Package: "tbgen"})
+		if err != nil {
+			t.Fatalf("Generate() error: %v", err)
+		}
+		if i > 0 && !bytes.Equal(first, again) {
+			t.Fatal("Generate() output differs between runs")
+		}
+		first = again
+	}
+
+	if strings.Index(string(first), "AFirstRow") > strings.Index(string(first), "PageViewsRow") {
+		t.Error("datasources are not sorted by name")
+	}
+}
+
+func TestGenerate_Meta(t *testing.T) {
+	project := testProject(t)
+	project.Pipes[0].Nodes[1].SQL = "SELECT * FROM {{ symbol(table) }}"
+
+	source, err := Generate(project, Config{Package: "tbgen"})
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if !strings.Contains(string(source), "type TopPagesRow = map[string]interface{}") {
+		t.Errorf("expected a map row when columns cannot be inferred:\n%s", source)
+	}
+
+	source, err = Generate(project, Config{
+		Package: "tbgen",
+		Meta:    map[string][]Column{"top_pages": {{Name: "total", Type: "Nullable(Float64)"}}},
+	})
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if !strings.Contains(string(source), "Total *float64 `json:\"total\"`") {
+		t.Errorf("meta columns not used:\n%s", source)
+	}
+}
+
+func TestGenerate_NameCollision(t *testing.T) {
+	project := testProject(t)
+	project.Datasources = append(project.Datasources, &datafile.Datasource{
+		Name:    "page-views",
+		Columns: []datafile.Column{{Name: "id", Type: "UInt64"}},
+	})
+
+	if _, err := Generate(project, Config{Package: "tbgen"}); err == nil {
+		t.Error("expected an error for datafiles generating the same identifiers")
+	}
+}
+
+func TestGenerate_UnrepresentableJSONPath(t *testing.T) {
+	project := testProject(t)
+	project.Datasources = append(project.Datasources, &datafile.Datasource{
+		Name: "orders",
+		Columns: []datafile.Column{
+			{Name: "id", Type: "UInt64", JSONPath: "$.id"},
+			{Name: "skus", Type: "Array(String)", JSONPath: "$.items[:].sku"},
+		},
+	})
+
+	_, err := Generate(project, Config{Package: "tbgen"})
+	if err == nil || !strings.Contains(err.Error(), "$.items[:].sku") {
+		t.Errorf("Generate() error = %v, want an error naming $.items[:].sku", err)
+	}
+}
@@ -0,0 +1,167 @@
+package codegen
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/NOLLYWOOD-COM/tinybird/internal/datafile"
+)
+
+// maxNodeDepth bounds how many nodes are followed when resolving column types
+const maxNodeDepth = 16
+
+var (
+	functionCallPattern = regexp.MustCompile(`^(\w+)\s*\((.*)\)$`)
+	castPattern         = regexp.MustCompile(`(?is)^CAST\s*\((.*?)\s+AS\s+(.+)\)$`)
+	castArgPattern      = regexp.MustCompile(`(?s)^CAST\s*\(.*,\s*'([^']+)'\s*\)$`)
+	shortCastPattern    = regexp.MustCompile(`^.+::\s*([\w(), ]+)$`)
+	stringLiteral       = regexp.MustCompile(`^'(?:[^'\\]|\\.)*'$`)
+	columnRef           = regexp.MustCompile("^(?:`?\\w+`?\\.)?`?(\\w+)`?$")
+)
+
+// functionTypes maps functions to the ClickHouse type of their result
+var functionTypes = map[string]string{
+	"count": "UInt64", "countIf": "UInt64", "countMerge": "UInt64",
+	"uniq": "UInt64", "uniqIf": "UInt64", "uniqMerge": "UInt64",
+	"uniqExact": "UInt64", "uniqExactIf": "UInt64", "uniqExactMerge": "UInt64",
+	"avg": "Float64", "avgIf": "Float64", "avgMerge": "Float64",
+	"toString": "String", "lower": "String", "upper": "String", "concat": "String",
+	"toDate": "Date", "toDateTime": "DateTime", "now": "DateTime", "today": "Date",
+	"toStartOfDay": "DateTime", "toStartOfHour": "DateTime", "toStartOfMinute": "DateTime",
+	"toStartOfMonth": "Date", "toStartOfWeek": "Date", "toStartOfYear": "Date",
+	"toInt8": "Int8", "toInt16": "Int16", "toInt32": "Int32", "toInt64": "Int64",
+	"toUInt8": "UInt8", "toUInt16": "UInt16", "toUInt32": "UInt32", "toUInt64": "UInt64",
+	"toFloat32": "Float32", "toFloat64": "Float64",
+}
+
+// passThroughFunctions return a value of the type of their first argument
+var passThroughFunctions = map[string]bool{
+	"any": true, "anyLast": true, "min": true, "max": true, "argMin": true, "argMax": true,
+	"minIf": true, "maxIf": true, "anyIf": true, "argMinIf": true, "argMaxIf": true,
+}
+
+// resolver infers the types of the columns returned by pipe nodes
+type resolver struct {
+	datasources map[string]*datafile.Datasource
+	pipes       map[string]*datafile.Pipe
+}
+
+// nodeColumns returns the output columns of a node with their inferred types,
+// or nil when the columns cannot be known
+func (r *resolver) nodeColumns(pipe *datafile.Pipe, node *datafile.Node, depth int) []Column {
+	outputs := datafile.OutputColumns(node.SQL)
+	if outputs == nil {
+		return nil
+	}
+
+	var source []Column
+	if depth < maxNodeDepth {
+		source = r.tableColumns(pipe, datafile.FromTable(node.SQL), depth+1)
+	}
+
+	columns := make([]Column, 0, len(outputs))
+	for _, output := range outputs {
+		if output.Name == "*" {
+			if source == nil {
+				return nil
+			}
+			columns = append(columns, source...)
+			continue
+		}
+		columns = append(columns, Column{Name: output.Name, Type: inferType(output.Expression, source)})
+	}
+	return columns
+}
+
+// tableColumns returns the columns of a node of pipe, a datasource or another endpoint
+func (r *resolver) tableColumns(pipe *datafile.Pipe, table string, depth int) []Column {
+	if table == "" {
+		return nil
+	}
+
+	if node := pipe.Node(table); node != nil {
+		return r.nodeColumns(pipe, node, depth)
+	}
+
+	if datasource, ok := r.datasources[table]; ok {
+		columns := make([]Column, len(datasource.Columns))
+		for i, column := range datasource.Columns {
+			columns[i] = Column{Name: column.Name, Type: column.Type}
+		}
+		return columns
+	}
+
+	if other, ok := r.pipes[table]; ok && other != pipe {
+		if endpoint := other.Endpoint(); endpoint != nil {
+			return r.nodeColumns(other, endpoint, depth)
+		}
+	}
+
+	return nil
+}
+
+// inferType returns the ClickHouse type of a SELECT expression, or an empty
+// string when it cannot be inferred
+func inferType(expression string, source []Column) string {
+	expression = strings.TrimSpace(expression)
+
+	if m := castPattern.FindStringSubmatch(expression); m != nil {
+		return strings.Trim(strings.TrimSpace(m[2]), "'")
+	}
+	if m := castArgPattern.FindStringSubmatch(expression); m != nil {
+		return m[1]
+	}
+	if m := shortCastPattern.FindStringSubmatch(expression); m != nil {
+		return strings.TrimSpace(m[1])
+	}
+	if stringLiteral.MatchString(expression) {
+		return "String"
+	}
+
+	if m := columnRef.FindStringSubmatch(expression); m != nil {
+		for _, column := range source {
+			if column.Name == m[1] {
+				return column.Type
+			}
+		}
+		return ""
+	}
+
+	if m := functionCallPattern.FindStringSubmatch(expression); m != nil && closesAtEnd(expression) {
+		if typ, ok := functionTypes[m[1]]; ok {
+			return typ
+		}
+		if passThroughFunctions[m[1]] {
+			args := strings.SplitN(m[2], ",", 2)
+			return inferType(args[0], source)
+		}
+		if strings.HasPrefix(m[1], "quantile") {
+			return "Float64"
+		}
+	}
+
+	// Parametric aggregates such as quantile(0.95)(duration)
+	if strings.HasPrefix(expression, "quantile") {
+		return "Float64"
+	}
+
+	return ""
+}
+
+// closesAtEnd reports whether the first opening parenthesis of expression is
+// closed by its last byte, as in f(x) but not in f(x) + g(y)
+func closesAtEnd(expression string) bool {
+	depth := 0
+	for i := strings.IndexByte(expression, '('); i < len(expression); i++ {
+		switch expression[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i == len(expression)-1
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NOLLYWOOD-COM/tinybird/internal/datafile"
+	"github.com/NOLLYWOOD-COM/tinybird/internal/jsonpath"
+)
+
+// jsonNode is a key of the JSON object a datasource row is sent as
+type jsonNode struct {
+	key      string
+	field    string // Go field holding the value, for leaves
+	children []*jsonNode
+}
+
+func (n *jsonNode) child(key string) *jsonNode {
+	for _, c := range n.children {
+		if c.key == key {
+			return c
+		}
+	}
+	c := &jsonNode{key: key}
+	n.children = append(n.children, c)
+	return c
+}
+
+// jsonPathTree returns the nested object described by the JSONPaths of columns,
+// or nil when every column maps to a top-level key named after it and struct
+// tags are enough. Paths that cannot be built from a flat row, such as fields
+// of array elements, are an error.
+func jsonPathTree(columns []datafile.Column) (*jsonNode, error) {
+	nested := false
+	root := &jsonNode{}
+
+	for _, column := range columns {
+		keys, err := objectKeys(column)
+		if err != nil {
+			return nil, err
+		}
+		if len(keys) != 1 || keys[0] != column.Name {
+			nested = true
+		}
+
+		node := root
+		for _, key := range keys {
+			if node.field != "" {
+				return nil, fmt.Errorf("column %s: JSONPath %s is nested under another column", column.Name, column.JSONPath)
+			}
+			node = node.child(key)
+		}
+		if node.field != "" || len(node.children) > 0 {
+			return nil, fmt.Errorf("column %s: JSONPath %s overlaps another column", column.Name, column.JSONPath)
+		}
+		node.field = GoName(column.Name)
+	}
+
+	if !nested {
+		return nil, nil
+	}
+	return root, nil
+}
+
+// objectKeys returns the object keys leading to the value of a column. A
+// trailing [:] is allowed since the column then holds the whole array.
+func objectKeys(column datafile.Column) ([]string, error) {
+	if column.JSONPath == "" {
+		return []string{column.Name}, nil
+	}
+
+	segments, err := jsonpath.Parse(strings.TrimSpace(column.JSONPath))
+	if err != nil {
+		return nil, fmt.Errorf("column %s: %w", column.Name, err)
+	}
+	if n := len(segments); n > 0 && segments[n-1].Wildcard {
+		segments = segments[:n-1]
+	}
+
+	keys := make([]string, len(segments))
+	for i, segment := range segments {
+		if segment.Wildcard {
+			return nil, fmt.Errorf("column %s: JSONPath %s selects fields of array elements, which a row struct cannot represent", column.Name, column.JSONPath)
+		}
+		keys[i] = segment.Field
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("column %s: JSONPath %s does not select an object key", column.Name, column.JSONPath)
+	}
+
+	return keys, nil
+}
+
+// jsonObject writes a map literal building the object of a jsonNode from a row r
+func (g *generator) jsonObject(node *jsonNode) {
+	g.printf("map[string]interface{}{\n")
+	for _, child := range node.children {
+		g.printf("%q: ", child.key)
+		if child.field != "" {
+			g.printf("r.%s", child.field)
+		} else {
+			g.jsonObject(child)
+		}
+		g.printf(",\n")
+	}
+	g.printf("}")
+}
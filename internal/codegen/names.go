@@ -0,0 +1,42 @@
+package codegen
+
+import (
+	"strings"
+	"unicode"
+)
+
+// initialisms are written in upper case in Go identifiers, following Go naming conventions
+var initialisms = map[string]bool{
+	"API": true, "CPU": true, "CSV": true, "DB": true, "HTML": true, "HTTP": true,
+	"HTTPS": true, "ID": true, "IP": true, "JSON": true, "OS": true, "SQL": true,
+	"TTL": true, "UI": true, "URI": true, "URL": true, "UTC": true, "UUID": true,
+}
+
+// GoName converts a Tinybird name such as user_id or top-pages into an exported
+// Go identifier such as UserID or TopPages
+func GoName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var sb strings.Builder
+	for _, word := range words {
+		upper := strings.ToUpper(word)
+		if initialisms[upper] {
+			sb.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+
+	result := sb.String()
+	if result == "" {
+		return "Field"
+	}
+	if unicode.IsDigit([]rune(result)[0]) {
+		return "X" + result
+	}
+	return result
+}
@@ -0,0 +1,35 @@
+package codegen
+
+import (
+	"strings"
+
+	"github.com/NOLLYWOOD-COM/tinybird/chtypes"
+)
+
+// GoType returns the Go type of values of a ClickHouse type, as decoded by
+// package chtypes, such as *time.Time for Nullable(DateTime) or chtypes.Decimal
+// for Decimal(18, 4). Types that cannot be parsed become interface{}.
+func GoType(chType string) string {
+	typ, err := chtypes.Parse(chType)
+	if err != nil {
+		return "interface{}"
+	}
+	return typ.GoType()
+}
+
+// ParamGoType returns the Go type of an endpoint parameter declared with a
+// template function such as Int32 or Array(name, 'String')
+func ParamGoType(function string, elementType string) string {
+	switch function {
+	case "Array":
+		return "[]" + ParamGoType(elementType, "")
+	case "Boolean":
+		return "bool"
+	case "Int", "Integer":
+		return "int"
+	case "Int8", "Int16", "Int32", "Int64", "UInt8", "UInt16", "UInt32", "UInt64", "Float32", "Float64":
+		return strings.ToLower(function)
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,330 @@
+// Package datafile parses Tinybird project files: .datasource files describing
+// a datasource schema and .pipe files describing the nodes of a pipe.
+//
+// Both formats are a sequence of KEYWORD value lines. A value of ">" starts a
+// block made of the following indented lines, as used by SCHEMA, SQL and
+// DESCRIPTION. Lines starting with # are comments.
+package datafile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Node types set with TYPE in a .pipe file
+const (
+	NodeTypeEndpoint     = "endpoint"
+	NodeTypeMaterialized = "materialized"
+	NodeTypeCopy         = "copy"
+	NodeTypeSink         = "sink"
+)
+
+// Column is a column of a datasource schema
+type Column struct {
+	Name     string
+	Type     string // ClickHouse type, e.g. Nullable(String)
+	JSONPath string // e.g. $.user.id, empty if the schema has none
+	Default  string // DEFAULT expression, if any
+	Codec    string // CODEC(...) clause, if any
+}
+
+// Datasource is a parsed .datasource file
+type Datasource struct {
+	Name        string
+	Description string
+	Columns     []Column
+	Settings    map[string]string // Other keywords such as ENGINE and ENGINE_SORTING_KEY
+}
+
+// Node is a node of a pipe
+type Node struct {
+	Name        string
+	Description string
+	SQL         string // Without the leading % template marker
+	Type        string // One of the NodeType constants, empty for intermediate nodes
+	Settings    map[string]string
+}
+
+// Pipe is a parsed .pipe file
+type Pipe struct {
+	Name        string
+	Description string
+	Nodes       []Node
+}
+
+// statement is a keyword with its value or block
+type statement struct {
+	keyword string
+	value   string
+	line    int
+}
+
+var keywordPattern = regexp.MustCompile(`^([A-Z][A-Z0-9_]*)(?:\s+(.*))?$`)
+
+// ReadDatasource parses the .datasource file at path, named after the file
+func ReadDatasource(path string) (*Datasource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseDatasource(fileName(path), f)
+}
+
+// ReadPipe parses the .pipe file at path, named after the file
+func ReadPipe(path string) (*Pipe, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParsePipe(fileName(path), f)
+}
+
+// ParseDatasource parses a .datasource file
+func ParseDatasource(name string, r io.Reader) (*Datasource, error) {
+	statements, err := parseStatements(name, r)
+	if err != nil {
+		return nil, err
+	}
+
+	datasource := &Datasource{Name: name, Settings: map[string]string{}}
+	for _, s := range statements {
+		switch s.keyword {
+		case "DESCRIPTION":
+			datasource.Description = s.value
+		case "SCHEMA":
+			columns, err := parseSchema(s.value)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", name, s.line, err)
+			}
+			datasource.Columns = columns
+		default:
+			datasource.Settings[s.keyword] = unquote(s.value)
+		}
+	}
+
+	if len(datasource.Columns) == 0 {
+		return nil, fmt.Errorf("%s: no SCHEMA", name)
+	}
+
+	return datasource, nil
+}
+
+// ParsePipe parses a .pipe file
+func ParsePipe(name string, r io.Reader) (*Pipe, error) {
+	statements, err := parseStatements(name, r)
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := &Pipe{Name: name}
+	var node *Node
+	for _, s := range statements {
+		if s.keyword == "NODE" {
+			pipe.Nodes = append(pipe.Nodes, Node{Name: unquote(s.value), Settings: map[string]string{}})
+			node = &pipe.Nodes[len(pipe.Nodes)-1]
+			continue
+		}
+
+		if node == nil {
+			if s.keyword == "DESCRIPTION" {
+				pipe.Description = s.value
+				continue
+			}
+			return nil, fmt.Errorf("%s:%d: %s outside of a NODE", name, s.line, s.keyword)
+		}
+
+		switch s.keyword {
+		case "DESCRIPTION":
+			node.Description = s.value
+		case "SQL":
+			node.SQL = stripTemplateMarker(s.value)
+		case "TYPE":
+			node.Type = strings.ToLower(unquote(s.value))
+		default:
+			node.Settings[s.keyword] = unquote(s.value)
+		}
+	}
+
+	return pipe, nil
+}
+
+// Endpoint returns the node published as an API endpoint, or nil
+func (p *Pipe) Endpoint() *Node {
+	for i := range p.Nodes {
+		if p.Nodes[i].Type == NodeTypeEndpoint {
+			return &p.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// Node returns the node with the given name, or nil
+func (p *Pipe) Node(name string) *Node {
+	for i := range p.Nodes {
+		if p.Nodes[i].Name == name {
+			return &p.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// parseStatements splits a datafile into keywords and their values or blocks
+func parseStatements(name string, r io.Reader) ([]statement, error) {
+	var statements []statement
+	var block []string
+	inBlock := false
+
+	flush := func() {
+		if inBlock {
+			statements[len(statements)-1].value = dedent(block)
+			block = nil
+			inBlock = false
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+
+		if inBlock && (line == "" || line[0] == ' ' || line[0] == '\t') {
+			block = append(block, line)
+			continue
+		}
+		flush()
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		m := keywordPattern.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("%s:%d: expected a keyword, got %q", name, lineNumber, line)
+		}
+
+		statements = append(statements, statement{keyword: m[1], value: strings.TrimSpace(m[2]), line: lineNumber})
+		if statements[len(statements)-1].value == ">" {
+			inBlock = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	return statements, nil
+}
+
+// dedent removes the indentation shared by the non-empty lines of a block
+func dedent(lines []string) string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			lines[i] = line[indent:]
+		} else {
+			lines[i] = strings.TrimLeft(line, " \t")
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// stripTemplateMarker removes the % line that enables templating in a node's SQL
+func stripTemplateMarker(sql string) string {
+	if rest, ok := strings.CutPrefix(sql, "%"); ok {
+		return strings.TrimSpace(rest)
+	}
+	return sql
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+func fileName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// Format returns the datasource as .datasource file text. ENGINE is written
+// first and the other settings in alphabetical order.
+func (d *Datasource) Format() string {
+	var sb strings.Builder
+
+	if d.Description != "" {
+		sb.WriteString("DESCRIPTION >\n")
+		writeBlock(&sb, d.Description)
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("SCHEMA >\n")
+	for i, column := range d.Columns {
+		fmt.Fprintf(&sb, "    `%s` %s", column.Name, column.Type)
+		if column.JSONPath != "" {
+			fmt.Fprintf(&sb, " `json:%s`", column.JSONPath)
+		}
+		if column.Default != "" {
+			fmt.Fprintf(&sb, " DEFAULT %s", column.Default)
+		}
+		if column.Codec != "" {
+			fmt.Fprintf(&sb, " %s", column.Codec)
+		}
+		if i < len(d.Columns)-1 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("\n")
+	}
+
+	keywords := make([]string, 0, len(d.Settings))
+	for keyword := range d.Settings {
+		if keyword != "ENGINE" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	sort.Strings(keywords)
+	if _, ok := d.Settings["ENGINE"]; ok {
+		keywords = append([]string{"ENGINE"}, keywords...)
+	}
+
+	if len(keywords) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, keyword := range keywords {
+		fmt.Fprintf(&sb, "%s \"%s\"\n", keyword, d.Settings[keyword])
+	}
+
+	return sb.String()
+}
+
+func writeBlock(sb *strings.Builder, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			sb.WriteString("\n")
+		} else {
+			sb.WriteString("    " + line + "\n")
+		}
+	}
+}
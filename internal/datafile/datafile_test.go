@@ -0,0 +1,175 @@
+package datafile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const pageViewsDatasource = `DESCRIPTION >
+    Page views sent from the web tracker
+
+# Columns
+SCHEMA >
+    ` + "`timestamp`" + ` DateTime ` + "`json:$.timestamp`" + `,
+    ` + "`session_id`" + ` String ` + "`json:$.session.id`" + `,
+    ` + "`country`" + ` Nullable(String) ` + "`json:$.geo.country`" + ` DEFAULT NULL CODEC(ZSTD(1)),
+    ` + "`amount`" + ` Decimal(18, 4) ` + "`json:$.amount`" + ` CODEC(Delta, ZSTD(1))
+
+ENGINE "MergeTree"
+ENGINE_SORTING_KEY "timestamp, session_id"
+`
+
+const topPagesPipe = `DESCRIPTION >
+    Most visited pages
+
+NODE filtered
+SQL >
+    %
+    SELECT * FROM page_views
+    WHERE timestamp >= {{ DateTime(start_date, '2024-01-01 00:00:00', description="Start, inclusive", required=True) }}
+    {% if defined(paths) %}
+      AND path IN {{ Array(paths, 'String') }}
+    {% end %}
+
+NODE endpoint
+SQL >
+    %
+    SELECT path, count() AS hits -- total
+    FROM filtered
+    LIMIT {{ Int32(limit, 10) }}
+TYPE Endpoint
+`
+
+func TestParseDatasource(t *testing.T) {
+	datasource, err := ParseDatasource("page_views", strings.NewReader(pageViewsDatasource))
+	if err != nil {
+		t.Fatalf("ParseDatasource() error: %v", err)
+	}
+
+	if datasource.Description != "Page views sent from the web tracker" {
+		t.Errorf("Description = %q", datasource.Description)
+	}
+
+	expected := []Column{
+		{Name: "timestamp", Type: "DateTime", JSONPath: "$.timestamp"},
+		{Name: "session_id", Type: "String", JSONPath: "$.session.id"},
+		{Name: "country", Type: "Nullable(String)", JSONPath: "$.geo.country", Default: "NULL", Codec: "CODEC(ZSTD(1))"},
+		{Name: "amount", Type: "Decimal(18, 4)", JSONPath: "$.amount", Codec: "CODEC(Delta, ZSTD(1))"},
+	}
+	if !reflect.DeepEqual(datasource.Columns, expected) {
+		t.Errorf("Columns = %+v, want %+v", datasource.Columns, expected)
+	}
+
+	if datasource.Settings["ENGINE"] != "MergeTree" || datasource.Settings["ENGINE_SORTING_KEY"] != "timestamp, session_id" {
+		t.Errorf("Settings = %v", datasource.Settings)
+	}
+}
+
+func TestParseDatasource_Errors(t *testing.T) {
+	tests := map[string]string{
+		"no schema":     "ENGINE \"MergeTree\"\n",
+		"not a keyword": "SCHEMA >\n    `a` String\nselect 1\n",
+		"no type":       "SCHEMA >\n    `a`\n",
+	}
+	for name, input := range tests {
+		if _, err := ParseDatasource("events", strings.NewReader(input)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestParsePipe(t *testing.T) {
+	pipe, err := ParsePipe("top_pages", strings.NewReader(topPagesPipe))
+	if err != nil {
+		t.Fatalf("ParsePipe() error: %v", err)
+	}
+
+	if pipe.Description != "Most visited pages" || len(pipe.Nodes) != 2 {
+		t.Fatalf("pipe = %+v", pipe)
+	}
+
+	endpoint := pipe.Endpoint()
+	if endpoint == nil || endpoint.Name != "endpoint" {
+		t.Fatalf("Endpoint() = %+v", endpoint)
+	}
+	if strings.HasPrefix(endpoint.SQL, "%") {
+		t.Errorf("SQL keeps the template marker: %q", endpoint.SQL)
+	}
+	if pipe.Node("filtered") == nil {
+		t.Error("Node(filtered) = nil")
+	}
+}
+
+func TestParams(t *testing.T) {
+	pipe, err := ParsePipe("top_pages", strings.NewReader(topPagesPipe))
+	if err != nil {
+		t.Fatalf("ParsePipe() error: %v", err)
+	}
+
+	params := Params(pipe.Nodes[0].SQL + "\n" + pipe.Nodes[1].SQL)
+
+	expected := []Param{
+		{Name: "limit", Type: "Int32", Default: "10"},
+		{Name: "paths", Type: "Array", ElementType: "String"},
+		{Name: "start_date", Type: "DateTime", Required: true, Default: "2024-01-01 00:00:00", Description: "Start, inclusive"},
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("Params() = %+v, want %+v", params, expected)
+	}
+}
+
+func TestOutputColumns(t *testing.T) {
+	sql := `WITH recent AS (SELECT a FROM t)
+		SELECT DISTINCT e.path, count() AS hits, CAST(x AS String) ` + "AS `label`" + `, max(ts)
+		FROM events AS e WHERE x = {{ String(x) }}`
+
+	columns := OutputColumns(sql)
+	expected := []OutputColumn{
+		{Name: "path", Expression: "e.path"},
+		{Name: "hits", Expression: "count()"},
+		{Name: "label", Expression: "CAST(x AS String)"},
+		{Name: "max(ts)", Expression: "max(ts)"},
+	}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("OutputColumns() = %+v, want %+v", columns, expected)
+	}
+
+	if table := FromTable(sql); table != "events" {
+		t.Errorf("FromTable() = %q, want events", table)
+	}
+	if table := FromTable("SELECT * FROM (SELECT 1)"); table != "" {
+		t.Errorf("FromTable() = %q for a subquery", table)
+	}
+	if columns := OutputColumns("SELECT * EXCEPT (a) FROM t"); columns != nil {
+		t.Errorf("OutputColumns() = %+v, want nil", columns)
+	}
+}
+
+func TestDatasourceFormat_RoundTrip(t *testing.T) {
+	datasource := &Datasource{
+		Name:        "events",
+		Description: "Events\n\nfrom the tracker",
+		Columns: []Column{
+			{Name: "timestamp", Type: "DateTime64(3)", JSONPath: "$.timestamp"},
+			{Name: "user_id", Type: "Nullable(String)", JSONPath: "$.user.id", Default: "NULL", Codec: "CODEC(ZSTD(1))"},
+		},
+		Settings: map[string]string{
+			"ENGINE_SORTING_KEY": "timestamp",
+			"ENGINE":             "MergeTree",
+		},
+	}
+
+	text := datasource.Format()
+	if !strings.Contains(text, "\nENGINE \"MergeTree\"\nENGINE_SORTING_KEY \"timestamp\"\n") {
+		t.Errorf("settings not written in order:\n%s", text)
+	}
+
+	parsed, err := ParseDatasource("events", strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("ParseDatasource() error: %v\n%s", err, text)
+	}
+	if !reflect.DeepEqual(parsed, datasource) {
+		t.Errorf("round trip = %+v, want %+v", parsed, datasource)
+	}
+}
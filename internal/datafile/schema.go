@@ -0,0 +1,147 @@
+package datafile
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var jsonPathPattern = regexp.MustCompile("`json:([^`]*)`")
+
+// parseSchema parses the column definitions of a SCHEMA block
+func parseSchema(schema string) ([]Column, error) {
+	var columns []Column
+	for _, definition := range splitTopLevel(schema, ',') {
+		definition = strings.TrimSpace(definition)
+		if definition == "" {
+			continue
+		}
+
+		column, err := parseColumn(definition)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// parseColumn parses a definition such as `user_id` String `json:$.user.id` DEFAULT 'none'
+func parseColumn(definition string) (Column, error) {
+	var column Column
+
+	if m := jsonPathPattern.FindStringSubmatchIndex(definition); m != nil {
+		column.JSONPath = strings.TrimSpace(definition[m[2]:m[3]])
+		definition = definition[:m[0]] + definition[m[1]:]
+	}
+
+	name, rest, err := cutIdentifier(definition)
+	if err != nil {
+		return column, fmt.Errorf("invalid column %q: %w", definition, err)
+	}
+	column.Name = name
+
+	// CODEC follows DEFAULT in a column definition
+	if i := indexTopLevelKeyword(rest, "CODEC"); i >= 0 {
+		column.Codec = strings.TrimSpace(rest[i:])
+		rest = rest[:i]
+	}
+	if i := indexTopLevelKeyword(rest, "DEFAULT"); i >= 0 {
+		column.Default = strings.TrimSpace(rest[i+len("DEFAULT"):])
+		rest = rest[:i]
+	}
+
+	column.Type = strings.TrimSpace(rest)
+	if column.Type == "" {
+		return column, fmt.Errorf("column %s has no type", name)
+	}
+
+	return column, nil
+}
+
+// cutIdentifier splits a leading, optionally backquoted, identifier from s
+func cutIdentifier(s string) (string, string, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "`") {
+		end := strings.Index(s[1:], "`")
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated identifier")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	}
+
+	end := strings.IndexAny(s, " \t\n")
+	if end < 0 {
+		return s, "", nil
+	}
+	return s[:end], s[end:], nil
+}
+
+// splitTopLevel splits s on sep outside of parentheses, brackets and quotes
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	start := 0
+	scanTopLevel(s, func(i int) bool {
+		if s[i] == sep {
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+		return true
+	})
+	return append(parts, s[start:])
+}
+
+// indexTopLevelKeyword returns the index of the first whole-word, case-insensitive
+// occurrence of keyword outside of parentheses and quotes, or -1
+func indexTopLevelKeyword(s string, keyword string) int {
+	found := -1
+	scanTopLevel(s, func(i int) bool {
+		if i+len(keyword) > len(s) || !strings.EqualFold(s[i:i+len(keyword)], keyword) {
+			return true
+		}
+		if (i > 0 && isIdentifierByte(s[i-1])) || (i+len(keyword) < len(s) && isIdentifierByte(s[i+len(keyword)])) {
+			return true
+		}
+		found = i
+		return false
+	})
+	return found
+}
+
+// scanTopLevel calls visit with the index of every byte of s at nesting depth 0
+// outside of quotes, until visit returns false
+func scanTopLevel(s string, visit func(i int) bool) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+			continue
+		case '(', '[', '{':
+			depth++
+			continue
+		case ')', ']', '}':
+			depth--
+			continue
+		}
+
+		if depth == 0 && !visit(i) {
+			return
+		}
+	}
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,279 @@
+package datafile
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Param is a parameter declared by a template function such as
+// {{ String(country, 'ES', description="Country code", required=True) }}
+type Param struct {
+	Name        string
+	Type        string // Template function, e.g. String, Int32, DateTime or Array
+	ElementType string // Element type of Array parameters, e.g. Int32
+	Required    bool
+	Default     string
+	Description string
+}
+
+// OutputColumn is a column of the result of a SELECT
+type OutputColumn struct {
+	Name       string
+	Expression string
+}
+
+var (
+	templatePattern      = regexp.MustCompile(`(?s)\{\{.*?\}\}|\{%.*?%\}`)
+	paramFunctionPattern = regexp.MustCompile(`\b(Array|Boolean|Column|DateTime64|DateTime|Date|Float32|Float64|Integer|Int128|Int256|Int16|Int32|Int64|Int8|Int|String|UInt128|UInt256|UInt16|UInt32|UInt64|UInt8)\s*\(`)
+	definedPattern       = regexp.MustCompile(`\bdefined\s*\(\s*([A-Za-z_]\w*)\s*\)`)
+	identifierPattern    = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+	columnRefPattern     = regexp.MustCompile("^(?:`?\\w+`?\\.)?`?(\\w+)`?$")
+	lineCommentPattern   = regexp.MustCompile(`--[^\n]*`)
+	blockCommentPattern  = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	whitespacePattern    = regexp.MustCompile(`\s+`)
+)
+
+// Params returns the parameters used in the templates of sql, sorted by name.
+// A parameter only checked with defined() is an optional String.
+func Params(sql string) []Param {
+	params := map[string]*Param{}
+	untyped := map[string]bool{}
+
+	for _, span := range templatePattern.FindAllString(sql, -1) {
+		for _, m := range paramFunctionPattern.FindAllStringSubmatchIndex(span, -1) {
+			end := matchingParen(span, m[1]-1)
+			if end < 0 {
+				continue
+			}
+			param, ok := parseParamCall(span[m[2]:m[3]], span[m[1]:end])
+			if !ok {
+				continue
+			}
+			if untyped[param.Name] {
+				delete(params, param.Name)
+				delete(untyped, param.Name)
+			}
+			mergeParam(params, param)
+		}
+
+		for _, m := range definedPattern.FindAllStringSubmatch(span, -1) {
+			if _, ok := params[m[1]]; !ok {
+				params[m[1]] = &Param{Name: m[1], Type: "String"}
+				untyped[m[1]] = true
+			}
+		}
+	}
+
+	result := make([]Param, 0, len(params))
+	for _, param := range params {
+		result = append(result, *param)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result
+}
+
+// parseParamCall parses the arguments of a template function call
+func parseParamCall(function string, args string) (Param, bool) {
+	parts := splitTopLevel(args, ',')
+	param := Param{Name: strings.TrimSpace(parts[0]), Type: function}
+	if !identifierPattern.MatchString(param.Name) {
+		return param, false
+	}
+
+	positional := 0
+	for _, part := range parts[1:] {
+		part = strings.TrimSpace(part)
+		if key, value, ok := cutKeywordArgument(part); ok {
+			switch key {
+			case "required":
+				param.Required = value == "True" || value == "true"
+			case "description":
+				param.Description = unquote(value)
+			case "default":
+				param.Default = unquote(value)
+			}
+			continue
+		}
+
+		positional++
+		switch {
+		case function == "Array" && positional == 1:
+			param.ElementType = unquote(part)
+		case function == "Array" && positional == 2, function != "Array" && positional == 1:
+			param.Default = unquote(part)
+		}
+	}
+
+	if function == "Array" && param.ElementType == "" {
+		param.ElementType = "String"
+	}
+
+	return param, true
+}
+
+// mergeParam records param, combining it with earlier uses of the same name
+func mergeParam(params map[string]*Param, param Param) {
+	existing, ok := params[param.Name]
+	if !ok {
+		params[param.Name] = &param
+		return
+	}
+
+	existing.Required = existing.Required || param.Required
+	if existing.Default == "" {
+		existing.Default = param.Default
+	}
+	if existing.Description == "" {
+		existing.Description = param.Description
+	}
+}
+
+// cutKeywordArgument splits a name=value argument
+func cutKeywordArgument(arg string) (string, string, bool) {
+	i := strings.IndexByte(arg, '=')
+	if i <= 0 || strings.HasPrefix(arg[i:], "==") {
+		return "", "", false
+	}
+	key := strings.TrimSpace(arg[:i])
+	if !identifierPattern.MatchString(key) {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(arg[i+1:]), true
+}
+
+// matchingParen returns the index of the parenthesis closing the one at open, or -1
+func matchingParen(s string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// OutputColumns returns the columns selected by the outermost SELECT of sql,
+// or nil when they cannot be known statically. A * or table.* expression is
+// returned as a column named *, standing for every column of FromTable.
+func OutputColumns(sql string) []OutputColumn {
+	list, ok := selectList(cleanSQL(sql))
+	if !ok {
+		return nil
+	}
+
+	var columns []OutputColumn
+	for _, expression := range splitTopLevel(list, ',') {
+		expression = strings.TrimSpace(whitespacePattern.ReplaceAllString(expression, " "))
+		if expression == "" {
+			continue
+		}
+		if expression == "*" || strings.HasSuffix(expression, ".*") {
+			columns = append(columns, OutputColumn{Name: "*", Expression: expression})
+			continue
+		}
+		if strings.HasPrefix(expression, "* ") {
+			return nil
+		}
+
+		column := OutputColumn{Name: expression, Expression: expression}
+		if i := lastIndexTopLevelKeyword(expression, "AS"); i > 0 {
+			column.Name = strings.Trim(strings.TrimSpace(expression[i+len("AS"):]), "`\"")
+			column.Expression = strings.TrimSpace(expression[:i])
+		} else if m := columnRefPattern.FindStringSubmatch(expression); m != nil {
+			column.Name = m[1]
+		}
+		columns = append(columns, column)
+	}
+
+	return columns
+}
+
+// FromTable returns the table, datasource or node read by the outermost FROM
+// of sql, or an empty string when it is a subquery or table function
+func FromTable(sql string) string {
+	sql = cleanSQL(sql)
+
+	selectAt := indexTopLevelKeyword(sql, "SELECT")
+	if selectAt < 0 {
+		return ""
+	}
+	rest := sql[selectAt:]
+	fromAt := indexTopLevelKeyword(rest, "FROM")
+	if fromAt < 0 {
+		return ""
+	}
+
+	name, tail, err := cutIdentifier(rest[fromAt+len("FROM"):])
+	if err != nil || strings.HasPrefix(strings.TrimSpace(tail), "(") || strings.ContainsAny(name, "()") {
+		return ""
+	}
+	return strings.Trim(name, "`")
+}
+
+// selectList returns the expressions between the outermost SELECT and its FROM
+func selectList(sql string) (string, bool) {
+	selectAt := indexTopLevelKeyword(sql, "SELECT")
+	if selectAt < 0 {
+		return "", false
+	}
+	list := sql[selectAt+len("SELECT"):]
+
+	if i := indexTopLevelKeyword(list, "FROM"); i >= 0 {
+		list = list[:i]
+	} else if i := indexTopLevelKeyword(list, "FORMAT"); i >= 0 {
+		list = list[:i]
+	}
+
+	list = strings.TrimSpace(list)
+	if len(list) > len("DISTINCT") && strings.EqualFold(list[:len("DISTINCT")], "DISTINCT") && !isIdentifierByte(list[len("DISTINCT")]) {
+		list = list[len("DISTINCT"):]
+	}
+
+	return list, strings.TrimSpace(list) != ""
+}
+
+// cleanSQL removes comments and template blocks so the SQL can be scanned
+func cleanSQL(sql string) string {
+	sql = blockCommentPattern.ReplaceAllString(sql, " ")
+	sql = lineCommentPattern.ReplaceAllString(sql, " ")
+	return templatePattern.ReplaceAllStringFunc(sql, func(span string) string {
+		if strings.HasPrefix(span, "{{") {
+			return "0"
+		}
+		return " "
+	})
+}
+
+// lastIndexTopLevelKeyword is like indexTopLevelKeyword but returns the last occurrence
+func lastIndexTopLevelKeyword(s string, keyword string) int {
+	last := -1
+	for offset := 0; offset < len(s); {
+		i := indexTopLevelKeyword(s[offset:], keyword)
+		if i < 0 {
+			break
+		}
+		last = offset + i
+		offset = last + len(keyword)
+	}
+	return last
+}
@@ -0,0 +1,228 @@
+package httpclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the API while a circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the state of a circuit breaker
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // Requests flow normally
+	BreakerOpen                         // Requests fail fast with ErrCircuitOpen
+	BreakerHalfOpen                     // A limited number of probe requests test for recovery
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerConfig configures circuit breaking around API requests
+type BreakerConfig struct {
+	FailureRatio     float64       // Ratio of failed requests in a window that opens the circuit
+	MinRequests      int           // Requests needed in a window before the ratio is evaluated
+	Window           time.Duration // Length of the window over which failures are counted
+	OpenTimeout      time.Duration // How long the circuit stays open before probing
+	HalfOpenRequests int           // Successful probes needed to close the circuit again
+	PerResource      bool          // Track each pipe and datasource separately, not just each host
+
+	// OnStateChange is called after a breaker changes state. key identifies the host or resource.
+	OnStateChange func(key string, from, to BreakerState)
+}
+
+const (
+	defaultBreakerFailureRatio = 0.5
+	defaultBreakerMinRequests  = 10
+	defaultBreakerWindow       = 30 * time.Second
+	defaultBreakerOpenTimeout  = 30 * time.Second
+)
+
+// breakerOutcome is the result of a request as far as the breaker is concerned
+type breakerOutcome int
+
+const (
+	outcomeSuccess breakerOutcome = iota
+	outcomeFailure
+	outcomeIgnored // e.g. the caller cancelled the request
+)
+
+// breakerSet holds one breaker per key
+type breakerSet struct {
+	config   BreakerConfig
+	now      func() time.Time
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+func newBreakerSet(config BreakerConfig) *breakerSet {
+	if config.FailureRatio <= 0 {
+		config.FailureRatio = defaultBreakerFailureRatio
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = defaultBreakerMinRequests
+	}
+	if config.Window <= 0 {
+		config.Window = defaultBreakerWindow
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = defaultBreakerOpenTimeout
+	}
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = 1
+	}
+
+	return &breakerSet{
+		config:   config,
+		now:      time.Now,
+		breakers: map[string]*breaker{},
+	}
+}
+
+func (s *breakerSet) get(urlStr string) *breaker {
+	key := resourceKey(urlStr, s.config.PerResource)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.breakers[key]
+	if !ok {
+		b = &breaker{set: s, key: key, windowStart: s.now()}
+		s.breakers[key] = b
+	}
+	return b
+}
+
+type breaker struct {
+	set *breakerSet
+	key string
+
+	mu          sync.Mutex
+	state       BreakerState
+	requests    int
+	failures    int
+	windowStart time.Time
+	openedAt    time.Time
+	probes      int    // Probes in flight while half-open
+	successes   int    // Successful probes while half-open
+	generation  uint64 // Incremented on every transition to discard stale outcomes
+}
+
+// allow reports whether a request may proceed, returning ErrCircuitOpen if not.
+// The returned generation must be passed to done.
+func (b *breaker) allow() (uint64, error) {
+	b.mu.Lock()
+
+	var from BreakerState
+	changed := false
+
+	if b.state == BreakerOpen && b.set.now().Sub(b.openedAt) >= b.set.config.OpenTimeout {
+		from, changed = b.state, true
+		b.transition(BreakerHalfOpen)
+	}
+
+	var err error
+	switch b.state {
+	case BreakerOpen:
+		err = ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.probes >= b.set.config.HalfOpenRequests {
+			err = ErrCircuitOpen
+		} else {
+			b.probes++
+		}
+	}
+
+	generation := b.generation
+	b.mu.Unlock()
+
+	if changed {
+		b.notify(from, BreakerHalfOpen)
+	}
+
+	return generation, err
+}
+
+// done records the outcome of a request that allow let through
+func (b *breaker) done(generation uint64, outcome breakerOutcome) {
+	b.mu.Lock()
+
+	// The breaker changed state while the request was in flight
+	if generation != b.generation {
+		b.mu.Unlock()
+		return
+	}
+
+	from := b.state
+	config := b.set.config
+	now := b.set.now()
+
+	switch b.state {
+	case BreakerHalfOpen:
+		b.probes--
+		switch outcome {
+		case outcomeFailure:
+			b.transition(BreakerOpen)
+		case outcomeSuccess:
+			b.successes++
+			if b.successes >= config.HalfOpenRequests {
+				b.transition(BreakerClosed)
+			}
+		}
+	case BreakerClosed:
+		if outcome == outcomeIgnored {
+			break
+		}
+
+		if now.Sub(b.windowStart) >= config.Window {
+			b.requests, b.failures, b.windowStart = 0, 0, now
+		}
+
+		b.requests++
+		if outcome == outcomeFailure {
+			b.failures++
+		}
+
+		if b.requests >= config.MinRequests && float64(b.failures)/float64(b.requests) >= config.FailureRatio {
+			b.transition(BreakerOpen)
+		}
+	}
+
+	to := b.state
+	b.mu.Unlock()
+
+	if from != to {
+		b.notify(from, to)
+	}
+}
+
+// transition moves the breaker to a new state and resets its counters. Callers hold b.mu.
+func (b *breaker) transition(to BreakerState) {
+	now := b.set.now()
+
+	b.state = to
+	b.generation++
+	b.requests, b.failures, b.windowStart = 0, 0, now
+	b.probes, b.successes = 0, 0
+	if to == BreakerOpen {
+		b.openedAt = now
+	}
+}
+
+func (b *breaker) notify(from, to BreakerState) {
+	if b.set.config.OnStateChange != nil {
+		b.set.config.OnStateChange(b.key, from, to)
+	}
+}
@@ -0,0 +1,150 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreaker_OpensAndRecovers(t *testing.T) {
+	var transitions []BreakerState
+	set := newBreakerSet(BreakerConfig{
+		FailureRatio: 0.5,
+		MinRequests:  4,
+		OpenTimeout:  time.Minute,
+		OnStateChange: func(key string, from, to BreakerState) {
+			transitions = append(transitions, to)
+		},
+	})
+
+	now := time.Unix(0, 0)
+	set.now = func() time.Time { return now }
+
+	b := set.get("https://api.tinybird.co/v0/pipes/top")
+
+	for _, outcome := range []breakerOutcome{outcomeSuccess, outcomeFailure, outcomeSuccess, outcomeFailure} {
+		generation, err := b.allow()
+		if err != nil {
+			t.Fatalf("allow() error while closed: %v", err)
+		}
+		b.done(generation, outcome)
+	}
+
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow() error = %v, want ErrCircuitOpen", err)
+	}
+
+	// After the open timeout a single probe is let through
+	now = now.Add(time.Minute)
+	generation, err := b.allow()
+	if err != nil {
+		t.Fatalf("allow() error while half-open: %v", err)
+	}
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second probe error = %v, want ErrCircuitOpen", err)
+	}
+
+	b.done(generation, outcomeSuccess)
+
+	if _, err := b.allow(); err != nil {
+		t.Errorf("allow() error after recovery: %v", err)
+	}
+
+	expected := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if len(transitions) != len(expected) {
+		t.Fatalf("transitions = %v, want %v", transitions, expected)
+	}
+	for i := range expected {
+		if transitions[i] != expected[i] {
+			t.Errorf("transitions = %v, want %v", transitions, expected)
+			break
+		}
+	}
+}
+
+func TestBreaker_FailedProbeReopens(t *testing.T) {
+	set := newBreakerSet(BreakerConfig{MinRequests: 1, OpenTimeout: time.Second})
+
+	now := time.Unix(0, 0)
+	set.now = func() time.Time { return now }
+
+	b := set.get("https://api.tinybird.co/v0/sql")
+
+	generation, _ := b.allow()
+	b.done(generation, outcomeFailure)
+
+	now = now.Add(time.Second)
+	generation, err := b.allow()
+	if err != nil {
+		t.Fatalf("allow() error while half-open: %v", err)
+	}
+	b.done(generation, outcomeFailure)
+
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("allow() error = %v, want ErrCircuitOpen after failed probe", err)
+	}
+}
+
+func TestClient_CircuitBreakerFailsFast(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := New(&Config{
+		MaxRetries: 0,
+		CircuitBreaker: &BreakerConfig{
+			MinRequests: 2,
+			OpenTimeout: time.Minute,
+			PerResource: true,
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		err := c.Get(context.Background(), server.URL+"/v0/pipes/slow", nil, nil)
+		if !errors.Is(err, ErrMaxRetriesExceeded) {
+			t.Fatalf("request %d error = %v, want ErrMaxRetriesExceeded", i, err)
+		}
+	}
+
+	err := c.Get(context.Background(), server.URL+"/v0/pipes/slow", nil, nil)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("error = %v, want ErrCircuitOpen", err)
+	}
+
+	if got := requests.Load(); got != 2 {
+		t.Errorf("server received %d requests, want 2", got)
+	}
+
+	// Other pipes on the same host have their own breaker
+	err = c.Get(context.Background(), server.URL+"/v0/pipes/other", nil, nil)
+	if errors.Is(err, ErrCircuitOpen) {
+		t.Error("expected a separate breaker for a different pipe")
+	}
+}
+
+func TestResourceKey(t *testing.T) {
+	tests := []struct {
+		url         string
+		perResource bool
+		expected    string
+	}{
+		{"https://api.tinybird.co/v0/pipes/top.json", false, "api.tinybird.co"},
+		{"https://api.tinybird.co/v0/pipes/top.json", true, "api.tinybird.co/pipe:top"},
+		{"https://api.tinybird.co/v0/events?name=clicks&wait=true", true, "api.tinybird.co/datasource:clicks"},
+		{"https://api.tinybird.co/v0/datasources/clicks/delete", true, "api.tinybird.co/datasource:clicks"},
+		{"https://api.tinybird.co/v0/sql", true, "api.tinybird.co"},
+	}
+
+	for _, tt := range tests {
+		if got := resourceKey(tt.url, tt.perResource); got != tt.expected {
+			t.Errorf("resourceKey(%q, %v) = %q, want %q", tt.url, tt.perResource, got, tt.expected)
+		}
+	}
+}
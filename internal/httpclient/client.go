@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -14,12 +15,23 @@ import (
 
 // New creates a new HTTP client with the given configuration
 func New(config *Config) Client {
-	return &client{
+	c := &client{
 		httpClient: &http.Client{
-			Timeout: config.Timeout,
+			Timeout:   config.Timeout,
+			Transport: newTransport(config.Transport),
 		},
 		config: config,
 	}
+
+	if config.CircuitBreaker != nil {
+		c.breakers = newBreakerSet(*config.CircuitBreaker)
+	}
+
+	if config.RateLimit != nil {
+		c.limiters = newLimiterSet(*config.RateLimit)
+	}
+
+	return c
 }
 
 func (c *client) Get(ctx context.Context, urlStr string, params map[string]string, result interface{}) error {
@@ -46,6 +58,14 @@ func (c *client) PostRaw(ctx context.Context, urlStr string, body []byte, conten
 	return c.executeRawWithRetry(ctx, http.MethodPost, urlStr, body, contentType, contentEncoding, result)
 }
 
+func (c *client) PostStream(ctx context.Context, urlStr string, getBody func() (io.ReadCloser, error), contentType string, contentEncoding string, result interface{}) error {
+	return c.executeStreamWithRetry(ctx, http.MethodPost, urlStr, getBody, contentType, contentEncoding, result)
+}
+
+func (c *client) PutRaw(ctx context.Context, urlStr string, body []byte, contentType string, contentEncoding string, result interface{}) error {
+	return c.executeRawWithRetry(ctx, http.MethodPut, urlStr, body, contentType, contentEncoding, result)
+}
+
 func (c *client) PostMultipart(ctx context.Context, urlStr string, fieldName string, fileName string, fileData []byte, result interface{}) error {
 	var buf bytes.Buffer
 	writer := multipart.NewWriter(&buf)
@@ -116,125 +136,161 @@ func (c *client) makeRequest(ctx context.Context, method, urlStr string, data in
 }
 
 func (c *client) executeWithRetry(ctx context.Context, method, urlStr string, bodyBytes []byte, result interface{}) error {
+	contentType := ""
+	if len(bodyBytes) > 0 {
+		contentType = "application/json"
+	}
+
+	return c.executeRawWithRetry(ctx, method, urlStr, bodyBytes, contentType, "", result)
+}
+
+func (c *client) executeRawWithRetry(ctx context.Context, method, urlStr string, bodyBytes []byte, contentType string, contentEncoding string, result interface{}) error {
+	var getBody func() (io.ReadCloser, error)
+	if len(bodyBytes) > 0 {
+		getBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(bodyBytes)), nil
+		}
+	}
+
+	return c.executeStreamWithRetry(ctx, method, urlStr, getBody, contentType, contentEncoding, result)
+}
+
+func (c *client) executeStreamWithRetry(ctx context.Context, method, urlStr string, getBody func() (io.ReadCloser, error), contentType string, contentEncoding string, result interface{}) error {
 	var lastErr error
 
-	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+	maxRetries := c.config.MaxRetries
+	if override := RequestOptionsFrom(ctx).MaxRetries; override != nil {
+		maxRetries = *override
+	}
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
 			// Wait before retrying with exponential backoff
 			time.Sleep(c.config.RetryDelay * time.Duration(attempt))
 		}
 
-		// Create fresh request for each attempt
-		var body io.Reader
-		if len(bodyBytes) > 0 {
-			body = bytes.NewReader(bodyBytes)
+		// Wait for a token, or fail fast, before spending the request
+		if c.limiters != nil {
+			if err := c.limiters.wait(ctx, urlStr); err != nil {
+				if errors.Is(err, ErrRateLimited) {
+					return fmt.Errorf("%w for %s", err, resourceKey(urlStr, true))
+				}
+				return err
+			}
 		}
 
-		req, err := http.NewRequestWithContext(ctx, method, urlStr, body)
-		if err != nil {
-			return fmt.Errorf("failed to create request: %w", err)
-		}
+		// Fail fast while the circuit for this host or resource is open
+		var cb *breaker
+		var generation uint64
+		if c.breakers != nil {
+			cb = c.breakers.get(urlStr)
 
-		// Set headers
-		if len(bodyBytes) > 0 {
-			req.Header.Set("Content-Type", "application/json")
+			var err error
+			if generation, err = cb.allow(); err != nil {
+				return fmt.Errorf("%w for %s", err, cb.key)
+			}
 		}
 
-		req.Header.Set("User-Agent", c.config.UserAgent)
+		retry, err := c.attempt(ctx, method, urlStr, getBody, contentType, contentEncoding, result)
 
-		if c.config.Token != "" {
-			req.Header.Set("Authorization", "Bearer "+c.config.Token)
+		if cb != nil {
+			cb.done(generation, breakerOutcomeOf(ctx, retry, err))
 		}
 
-		// Execute request
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			lastErr = fmt.Errorf("request failed: %w", err)
-			continue
-		}
-
-		// Handle response
-		lastErr = c.handleResponse(resp, result)
-
-		// Close response body immediately
-		resp.Body.Close()
-
-		// Check if we should retry
-		if lastErr == nil {
+		if err == nil {
 			return nil
 		}
 
-		// Don't retry on client errors (4xx except 429)
-		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
-			return lastErr
-		}
-
-		// Retry on server errors (5xx) and rate limiting (429)
-		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
-			continue
+		lastErr = err
+		if !retry {
+			return err
 		}
-
-		// For other errors, don't retry
-		return lastErr
 	}
 
-	return fmt.Errorf("max retries exceeded: %w", lastErr)
+	return fmt.Errorf("%w: %w", ErrMaxRetriesExceeded, lastErr)
 }
 
-func (c *client) executeRawWithRetry(ctx context.Context, method, urlStr string, bodyBytes []byte, contentType string, contentEncoding string, result interface{}) error {
-	var lastErr error
-
-	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
-		if attempt > 0 {
-			time.Sleep(c.config.RetryDelay * time.Duration(attempt))
-		}
-
-		var body io.Reader
-		if len(bodyBytes) > 0 {
-			body = bytes.NewReader(bodyBytes)
-		}
-
-		req, err := http.NewRequestWithContext(ctx, method, urlStr, body)
+// attempt performs a single request. It reports whether a failed request should be retried.
+func (c *client) attempt(ctx context.Context, method, urlStr string, getBody func() (io.ReadCloser, error), contentType string, contentEncoding string, result interface{}) (bool, error) {
+	// Create a fresh body for each attempt
+	var body io.Reader
+	if getBody != nil {
+		rc, err := getBody()
 		if err != nil {
-			return fmt.Errorf("failed to create request: %w", err)
+			return false, fmt.Errorf("failed to open request body: %w", err)
 		}
+		body = rc
+	}
 
-		if contentType != "" {
-			req.Header.Set("Content-Type", contentType)
-		}
-		if contentEncoding != "" {
-			req.Header.Set("Content-Encoding", contentEncoding)
-		}
-		if c.config.Token != "" {
-			req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, body)
+	if err != nil {
+		if closer, ok := body.(io.Closer); ok {
+			closer.Close()
 		}
-		req.Header.Set("User-Agent", c.config.UserAgent)
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
 
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			lastErr = fmt.Errorf("request failed: %w", err)
-			continue
-		}
+	// Set headers
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+	overrides := RequestOptionsFrom(ctx)
 
-		lastErr = c.handleResponse(resp, result)
-		resp.Body.Close()
+	token := c.config.Token
+	if overrides.Token != "" {
+		token = overrides.Token
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("User-Agent", c.config.UserAgent)
+	for name, value := range overrides.Headers {
+		req.Header.Set(name, value)
+	}
 
-		if lastErr == nil {
-			return nil
-		}
+	// Execute request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("request failed: %w", err)
+	}
 
-		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
-			return lastErr
-		}
+	if c.limiters != nil {
+		c.limiters.observe(urlStr, resp)
+	}
 
-		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
-			continue
-		}
+	// Handle response
+	err = c.handleResponse(resp, result)
+
+	// Close response body immediately
+	resp.Body.Close()
 
-		return lastErr
+	if err == nil {
+		return false, nil
 	}
 
-	return fmt.Errorf("max retries exceeded: %w", lastErr)
+	// Retry on server errors (5xx) and rate limiting (429), but not on other client errors
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+
+	return retry, err
+}
+
+// breakerOutcomeOf classifies a request result for the circuit breaker
+func breakerOutcomeOf(ctx context.Context, retry bool, err error) breakerOutcome {
+	switch {
+	case err == nil:
+		return outcomeSuccess
+	case ctx.Err() != nil:
+		// The caller gave up, which says nothing about the API's health
+		return outcomeIgnored
+	case retry:
+		return outcomeFailure
+	default:
+		// The API answered, even if it rejected the request
+		return outcomeSuccess
+	}
 }
 
 func (c *client) handleResponse(resp *http.Response, result interface{}) error {
@@ -256,8 +312,9 @@ func (c *client) handleResponse(resp *http.Response, result interface{}) error {
 	}
 
 	// Error response - include body in error message
-	if len(body) > 0 {
-		return fmt.Errorf("HTTP %d: %s - %s", resp.StatusCode, resp.Status, string(body))
+	return &HTTPError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       string(body),
 	}
-	return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
 }
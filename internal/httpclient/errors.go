@@ -0,0 +1,23 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMaxRetriesExceeded is wrapped by errors returned after every retry attempt failed
+var ErrMaxRetriesExceeded = errors.New("max retries exceeded")
+
+// HTTPError is returned when the API responds with a non-2xx status code
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	if e.Body != "" {
+		return fmt.Sprintf("HTTP %d: %s - %s", e.StatusCode, e.Status, e.Body)
+	}
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Status)
+}
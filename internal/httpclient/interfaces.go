@@ -1,6 +1,9 @@
 package httpclient
 
-import "context"
+import (
+	"context"
+	"io"
+)
 
 // Client is the internal HTTP client interface for making requests
 type Client interface {
@@ -10,5 +13,9 @@ type Client interface {
 	Post(ctx context.Context, url string, body interface{}, result interface{}) error
 	PostMultipart(ctx context.Context, url string, fieldName string, fileName string, fileData []byte, result interface{}) error
 	PostRaw(ctx context.Context, url string, body []byte, contentType string, contentEncoding string, result interface{}) error
+	// PostStream posts a body produced by getBody, which is called once per attempt
+	// so that retries can replay the request body from the start.
+	PostStream(ctx context.Context, url string, getBody func() (io.ReadCloser, error), contentType string, contentEncoding string, result interface{}) error
 	Put(ctx context.Context, url string, body interface{}, result interface{}) error
+	PutRaw(ctx context.Context, url string, body []byte, contentType string, contentEncoding string, result interface{}) error
 }
@@ -0,0 +1,256 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned in fail-fast mode when a request would exceed a client-side rate limit
+var ErrRateLimited = errors.New("client-side rate limit exceeded")
+
+// RateLimit is a token bucket limit
+type RateLimit struct {
+	RequestsPerSecond float64 // Sustained request rate
+	Burst             int     // Maximum requests allowed at once, defaults to 1
+}
+
+// RateLimitConfig configures client-side rate limiting
+type RateLimitConfig struct {
+	Global      *RateLimit           // Limit shared by every request made with this client
+	Pipes       map[string]RateLimit // Limits per pipe, keyed by pipe name
+	Datasources map[string]RateLimit // Limits per datasource, keyed by datasource name
+	FailFast    bool                 // Return ErrRateLimited instead of waiting for a token
+	AutoTune    bool                 // Honour X-RateLimit-* and Retry-After response headers
+}
+
+// limiterSet holds the global bucket and one bucket per pipe or datasource
+type limiterSet struct {
+	config RateLimitConfig
+	now    func() time.Time
+	global *tokenBucket
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newLimiterSet(config RateLimitConfig) *limiterSet {
+	s := &limiterSet{
+		config:  config,
+		now:     time.Now,
+		buckets: map[string]*tokenBucket{},
+	}
+
+	if config.Global != nil {
+		s.global = newTokenBucket(*config.Global, s.now())
+	}
+
+	return s
+}
+
+// wait blocks until the request may be sent, or fails fast if configured to
+func (s *limiterSet) wait(ctx context.Context, urlStr string) error {
+	if s.global != nil {
+		if err := s.global.wait(ctx, s.now, s.config.FailFast); err != nil {
+			return err
+		}
+	}
+
+	if bucket := s.bucket(urlStr, false); bucket != nil {
+		if err := bucket.wait(ctx, s.now, s.config.FailFast); err != nil {
+			if s.global != nil {
+				s.global.refund()
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// observe adjusts the resource bucket from the rate limit headers of a response
+func (s *limiterSet) observe(urlStr string, resp *http.Response) {
+	if !s.config.AutoTune {
+		return
+	}
+
+	now := s.now()
+	var blockedUntil time.Time
+
+	remaining, hasRemaining := headerInt(resp.Header, "X-RateLimit-Remaining")
+	if reset, ok := headerInt(resp.Header, "X-RateLimit-Reset"); ok && hasRemaining && remaining == 0 {
+		blockedUntil = now.Add(time.Duration(reset) * time.Second)
+	}
+	if retryAfter, ok := headerInt(resp.Header, "Retry-After"); ok && resp.StatusCode == http.StatusTooManyRequests {
+		if until := now.Add(time.Duration(retryAfter) * time.Second); until.After(blockedUntil) {
+			blockedUntil = until
+		}
+	}
+
+	if !hasRemaining && blockedUntil.IsZero() {
+		return
+	}
+
+	bucket := s.bucket(urlStr, true)
+	if bucket == nil {
+		return
+	}
+
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+
+	if blockedUntil.After(bucket.blockedUntil) {
+		bucket.blockedUntil = blockedUntil
+	}
+	if hasRemaining && bucket.rate > 0 {
+		bucket.refill(now)
+		bucket.tokens = math.Min(bucket.tokens, float64(remaining))
+	}
+}
+
+// bucket returns the bucket for the pipe or datasource in urlStr, or for all
+// other requests when urlStr names neither. Resources without a configured
+// limit only get a bucket when create is set, which happens when response
+// headers need to be tracked.
+func (s *limiterSet) bucket(urlStr string, create bool) *tokenBucket {
+	parsed, err := url.Parse(urlStr)
+	if err != nil {
+		return nil
+	}
+
+	name := resourceName(parsed)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if bucket, ok := s.buckets[name]; ok {
+		return bucket
+	}
+
+	limit, ok := s.configuredLimit(name)
+	if !ok && !create {
+		return nil
+	}
+
+	bucket := newTokenBucket(limit, s.now())
+	s.buckets[name] = bucket
+	return bucket
+}
+
+func (s *limiterSet) configuredLimit(resource string) (RateLimit, bool) {
+	if name, ok := strings.CutPrefix(resource, "pipe:"); ok {
+		limit, ok := s.config.Pipes[name]
+		return limit, ok
+	}
+	if name, ok := strings.CutPrefix(resource, "datasource:"); ok {
+		limit, ok := s.config.Datasources[name]
+		return limit, ok
+	}
+	return RateLimit{}, false
+}
+
+// tokenBucket is a token bucket limiter. A zero rate means unlimited, in which
+// case the bucket only enforces blocks learned from response headers.
+type tokenBucket struct {
+	mu           sync.Mutex
+	rate         float64
+	burst        float64
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time
+}
+
+func newTokenBucket(limit RateLimit, now time.Time) *tokenBucket {
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &tokenBucket{
+		rate:   limit.RequestsPerSecond,
+		burst:  burst,
+		tokens: burst,
+		last:   now,
+	}
+}
+
+// refill adds the tokens accrued since the last update. Callers hold b.mu.
+func (b *tokenBucket) refill(now time.Time) {
+	if b.rate > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+}
+
+// reserve takes a token and returns how long the caller must wait before using it
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var delay time.Duration
+	if b.rate > 0 {
+		b.refill(now)
+		b.tokens--
+		if b.tokens < 0 {
+			delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+		}
+	}
+
+	if blocked := b.blockedUntil.Sub(now); blocked > delay {
+		delay = blocked
+	}
+
+	return delay
+}
+
+// refund returns a token taken by reserve that was not used
+func (b *tokenBucket) refund() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rate > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+1)
+	}
+}
+
+func (b *tokenBucket) wait(ctx context.Context, now func() time.Time, failFast bool) error {
+	delay := b.reserve(now())
+	if delay <= 0 {
+		return nil
+	}
+
+	if failFast {
+		b.refund()
+		return ErrRateLimited
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		b.refund()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func headerInt(header http.Header, name string) (int, bool) {
+	value := header.Get(name)
+	if value == "" {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,121 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiter_FailFastRefills(t *testing.T) {
+	set := newLimiterSet(RateLimitConfig{
+		Global:   &RateLimit{RequestsPerSecond: 2, Burst: 2},
+		FailFast: true,
+	})
+
+	now := time.Unix(0, 0)
+	set.now = func() time.Time { return now }
+	set.global = newTokenBucket(*set.config.Global, now)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := set.wait(ctx, "https://api.tinybird.co/v0/sql"); err != nil {
+			t.Fatalf("request %d error = %v, want burst to allow it", i, err)
+		}
+	}
+
+	if err := set.wait(ctx, "https://api.tinybird.co/v0/sql"); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("error = %v, want ErrRateLimited", err)
+	}
+
+	// Half a second at 2 req/s earns one token
+	now = now.Add(500 * time.Millisecond)
+	if err := set.wait(ctx, "https://api.tinybird.co/v0/sql"); err != nil {
+		t.Errorf("error after refill = %v", err)
+	}
+}
+
+func TestLimiter_PerResource(t *testing.T) {
+	set := newLimiterSet(RateLimitConfig{
+		Pipes:       map[string]RateLimit{"top": {RequestsPerSecond: 1}},
+		Datasources: map[string]RateLimit{"clicks": {RequestsPerSecond: 1}},
+		FailFast:    true,
+	})
+
+	ctx := context.Background()
+	urls := []string{
+		"https://api.tinybird.co/v0/pipes/top.json",
+		"https://api.tinybird.co/v0/events?name=clicks",
+	}
+
+	for _, u := range urls {
+		if err := set.wait(ctx, u); err != nil {
+			t.Fatalf("first request to %s error = %v", u, err)
+		}
+		if err := set.wait(ctx, u); !errors.Is(err, ErrRateLimited) {
+			t.Errorf("second request to %s error = %v, want ErrRateLimited", u, err)
+		}
+	}
+
+	// Unconfigured resources are not limited
+	for i := 0; i < 3; i++ {
+		if err := set.wait(ctx, "https://api.tinybird.co/v0/pipes/other.json"); err != nil {
+			t.Fatalf("unlimited pipe error = %v", err)
+		}
+	}
+}
+
+func TestLimiter_BlockingRespectsContext(t *testing.T) {
+	set := newLimiterSet(RateLimitConfig{
+		Global: &RateLimit{RequestsPerSecond: 0.1},
+	})
+
+	if err := set.wait(context.Background(), "https://api.tinybird.co/v0/sql"); err != nil {
+		t.Fatalf("first request error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := set.wait(ctx, "https://api.tinybird.co/v0/sql"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestClient_RateLimitAutoTune(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-RateLimit-Limit", "10")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "60")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := New(&Config{
+		RateLimit: &RateLimitConfig{FailFast: true, AutoTune: true},
+	})
+
+	ctx := context.Background()
+	if err := c.Get(ctx, server.URL+"/v0/pipes/top.json", nil, nil); err != nil {
+		t.Fatalf("first request error = %v", err)
+	}
+
+	// The quota is exhausted until the reset, so the next call never reaches the server
+	err := c.Get(ctx, server.URL+"/v0/pipes/top.json", nil, nil)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("error = %v, want ErrRateLimited", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("server received %d requests, want 1", got)
+	}
+
+	// Other pipes have their own quota
+	if err := c.Get(ctx, server.URL+"/v0/pipes/other.json", nil, nil); err != nil {
+		t.Errorf("other pipe error = %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package httpclient
+
+import "context"
+
+// RequestOptions override the client configuration for the requests made with a context
+type RequestOptions struct {
+	Token      string            // Bearer token replacing Config.Token, if set
+	MaxRetries *int              // Retry count replacing Config.MaxRetries, if set
+	Headers    map[string]string // Extra headers, which may override the default ones
+}
+
+type requestOptionsKey struct{}
+
+// WithRequestOptions returns a context whose requests use the given overrides
+func WithRequestOptions(ctx context.Context, options RequestOptions) context.Context {
+	return context.WithValue(ctx, requestOptionsKey{}, options)
+}
+
+// RequestOptionsFrom returns the overrides set on ctx with WithRequestOptions
+func RequestOptionsFrom(ctx context.Context) RequestOptions {
+	options, _ := ctx.Value(requestOptionsKey{}).(RequestOptions)
+	return options
+}
@@ -0,0 +1,46 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_RequestOptionsOverrideConfig(t *testing.T) {
+	var requests atomic.Int32
+	var authorization, requestID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		authorization = r.Header.Get("Authorization")
+		requestID = r.Header.Get("X-Request-Id")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := New(&Config{Token: "default", MaxRetries: 3})
+
+	retries := 0
+	ctx := WithRequestOptions(context.Background(), RequestOptions{
+		Token:      "override",
+		MaxRetries: &retries,
+		Headers:    map[string]string{"X-Request-Id": "abc"},
+	})
+
+	err := c.Get(ctx, server.URL+"/v0/pipes/top", nil, nil)
+	if !errors.Is(err, ErrMaxRetriesExceeded) {
+		t.Fatalf("error = %v, want ErrMaxRetriesExceeded", err)
+	}
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("server received %d requests, want 1", got)
+	}
+	if authorization != "Bearer override" {
+		t.Errorf("Authorization = %q, want the overriding token", authorization)
+	}
+	if requestID != "abc" {
+		t.Errorf("X-Request-Id = %q, want abc", requestID)
+	}
+}
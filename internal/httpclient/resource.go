@@ -0,0 +1,51 @@
+package httpclient
+
+import (
+	"net/url"
+	"strings"
+)
+
+// resourceKey identifies the host and, optionally, the pipe or datasource a request targets.
+// It returns keys such as "api.tinybird.co", "api.tinybird.co/pipe:top_pages" or
+// "api.tinybird.co/datasource:events".
+func resourceKey(urlStr string, perResource bool) string {
+	parsed, err := url.Parse(urlStr)
+	if err != nil {
+		return urlStr
+	}
+
+	if !perResource {
+		return parsed.Host
+	}
+
+	if resource := resourceName(parsed); resource != "" {
+		return parsed.Host + "/" + resource
+	}
+	return parsed.Host
+}
+
+// resourceName extracts "pipe:{name}" or "datasource:{name}" from a versioned API URL
+func resourceName(parsed *url.URL) string {
+	// Paths look like /v0/pipes/{name}, /v0/events?name={name} or /v0/datasources/{name}/...
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+
+	switch parts[1] {
+	case "pipes":
+		if len(parts) > 2 {
+			return "pipe:" + strings.TrimSuffix(parts[2], ".json")
+		}
+	case "events":
+		if name := parsed.Query().Get("name"); name != "" {
+			return "datasource:" + name
+		}
+	case "datasources":
+		if len(parts) > 2 {
+			return "datasource:" + parts[2]
+		}
+	}
+
+	return ""
+}
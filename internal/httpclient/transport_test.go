@@ -0,0 +1,55 @@
+package httpclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewTransport_Defaults(t *testing.T) {
+	transport := newTransport(TransportConfig{})
+
+	if transport.MaxIdleConns != defaultMaxIdleConns {
+		t.Errorf("MaxIdleConns = %d, want %d", transport.MaxIdleConns, defaultMaxIdleConns)
+	}
+	if transport.IdleConnTimeout != defaultIdleConnTimeout {
+		t.Errorf("IdleConnTimeout = %v, want %v", transport.IdleConnTimeout, defaultIdleConnTimeout)
+	}
+	if !transport.ForceAttemptHTTP2 {
+		t.Error("expected HTTP/2 to be enabled by default")
+	}
+	if transport.TLSClientConfig != nil {
+		t.Error("expected no TLS session cache by default")
+	}
+}
+
+func TestNewTransport_CustomConfig(t *testing.T) {
+	transport := newTransport(TransportConfig{
+		MaxIdleConnsPerHost: 256,
+		MaxConnsPerHost:     512,
+		IdleConnTimeout:     2 * time.Minute,
+		DisableHTTP2:        true,
+		TLSSessionCacheSize: 64,
+	})
+
+	if transport.MaxIdleConnsPerHost != 256 {
+		t.Errorf("MaxIdleConnsPerHost = %d, want 256", transport.MaxIdleConnsPerHost)
+	}
+	if transport.MaxIdleConns != 256 {
+		t.Errorf("MaxIdleConns = %d, want 256 (raised to per-host limit)", transport.MaxIdleConns)
+	}
+	if transport.MaxConnsPerHost != 512 {
+		t.Errorf("MaxConnsPerHost = %d, want 512", transport.MaxConnsPerHost)
+	}
+	if transport.IdleConnTimeout != 2*time.Minute {
+		t.Errorf("IdleConnTimeout = %v, want 2m", transport.IdleConnTimeout)
+	}
+	if transport.ForceAttemptHTTP2 {
+		t.Error("expected HTTP/2 to be disabled")
+	}
+	if transport.TLSNextProto == nil {
+		t.Error("expected TLSNextProto to be set when HTTP/2 is disabled")
+	}
+	if transport.TLSClientConfig == nil || transport.TLSClientConfig.ClientSessionCache == nil {
+		t.Error("expected TLS session cache to be configured")
+	}
+}
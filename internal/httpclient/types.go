@@ -9,6 +9,8 @@ import (
 type client struct {
 	httpClient *http.Client
 	config     *Config
+	breakers   *breakerSet
+	limiters   *limiterSet
 }
 
 // Config holds configuration for the HTTP client
@@ -18,4 +20,20 @@ type Config struct {
 	MaxRetries int
 	UserAgent  string
 	Token      string
+	Transport  TransportConfig
+
+	CircuitBreaker *BreakerConfig   // Optional circuit breaking, disabled when nil
+	RateLimit      *RateLimitConfig // Optional client-side rate limiting, disabled when nil
+}
+
+// TransportConfig holds connection pooling and protocol settings for the
+// underlying http.Transport. Zero values fall back to the net/http defaults.
+type TransportConfig struct {
+	MaxIdleConns        int           // Maximum idle connections across all hosts
+	MaxIdleConnsPerHost int           // Maximum idle connections kept per host
+	MaxConnsPerHost     int           // Maximum total connections per host, 0 means unlimited
+	IdleConnTimeout     time.Duration // How long an idle connection is kept in the pool
+	DisableHTTP2        bool          // Disable HTTP/2 negotiation
+	KeepAlive           time.Duration // TCP keepalive interval, negative disables keepalives
+	TLSSessionCacheSize int           // Number of TLS sessions cached for resumption, 0 disables caching
 }
@@ -0,0 +1,88 @@
+// Package jsonpath parses and evaluates the subset of JSONPath used by Tinybird
+// datasource schemas: dotted field access such as $.user.id, bracketed field
+// names such as $['user-agent'] and the [:] array wildcard.
+package jsonpath
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Segment is a single step of a path such as $.user.tags[:]
+type Segment struct {
+	Field    string
+	Wildcard bool // [:] selects every element of an array
+}
+
+// Parse splits path into its segments
+func Parse(path string) ([]Segment, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid JSONPath %q: must start with $", path)
+	}
+
+	var segments []Segment
+	rest := path[1:]
+
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "[:]"):
+			segments = append(segments, Segment{Wildcard: true})
+			rest = rest[3:]
+		case strings.HasPrefix(rest, "['"):
+			end := strings.Index(rest[2:], "']")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: unterminated bracket", path)
+			}
+			segments = append(segments, Segment{Field: rest[2 : 2+end]})
+			rest = rest[2+end+2:]
+		case strings.HasPrefix(rest, "."):
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: empty field name", path)
+			}
+			segments = append(segments, Segment{Field: rest[1 : 1+end]})
+			rest = rest[1+end:]
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q", path, rest)
+		}
+	}
+
+	return segments, nil
+}
+
+// Eval resolves segments against a decoded JSON value. A wildcard collects the
+// remainder of the path from every array element. The second result is false
+// if the path does not exist in the value.
+func Eval(value interface{}, segments []Segment) (interface{}, bool) {
+	for i, segment := range segments {
+		if segment.Wildcard {
+			array, ok := value.([]interface{})
+			if !ok {
+				return nil, false
+			}
+
+			results := make([]interface{}, 0, len(array))
+			for _, elem := range array {
+				if result, ok := Eval(elem, segments[i+1:]); ok {
+					results = append(results, result)
+				}
+			}
+			return results, true
+		}
+
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		value, ok = object[segment.Field]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
@@ -0,0 +1,49 @@
+package jsonpath
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	segments, err := Parse("$.items[:].name['first name']")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Segment{
+		{Field: "items"},
+		{Wildcard: true},
+		{Field: "name"},
+		{Field: "first name"},
+	}
+
+	if !reflect.DeepEqual(segments, expected) {
+		t.Errorf("Parse() = %+v, want %+v", segments, expected)
+	}
+
+	if _, err := Parse("items.name"); err == nil {
+		t.Error("expected error for path without $ prefix")
+	}
+}
+
+func TestEval(t *testing.T) {
+	value := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"sku": "a"},
+			map[string]interface{}{"qty": 1.0},
+			map[string]interface{}{"sku": "b"},
+		},
+	}
+
+	segments, _ := Parse("$.items[:].sku")
+	got, ok := Eval(value, segments)
+	if !ok || !reflect.DeepEqual(got, []interface{}{"a", "b"}) {
+		t.Errorf("Eval() = %v, %v, want [a b], true", got, ok)
+	}
+
+	segments, _ = Parse("$.missing")
+	if _, ok := Eval(value, segments); ok {
+		t.Error("Eval() found a missing field")
+	}
+}
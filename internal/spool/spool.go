@@ -0,0 +1,567 @@
+// Package spool implements a durable, append-only FIFO queue of byte payloads
+// stored as checksummed records in segment files on disk.
+//
+// Each record is framed as a 4-byte big-endian payload length, a 4-byte CRC-32C
+// of the payload, and the payload itself. A cursor file records the position of
+// the oldest unacknowledged record. On open, torn or corrupt records at the tail
+// of a segment (for example after a crash mid-write) are truncated away. Records
+// found corrupt while reading are skipped and counted in Stats.Corrupt.
+package spool
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	headerSize         = 8
+	segmentExt         = ".seg"
+	cursorFile         = "cursor"
+	defaultSegmentSize = 16 * 1024 * 1024
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrFull is returned by Append when the record would exceed Options.MaxBytes
+var ErrFull = errors.New("spool is full")
+
+// ErrClosed is returned by operations on a closed spool
+var ErrClosed = errors.New("spool is closed")
+
+// Options configures a spool
+type Options struct {
+	MaxBytes    int64 // Maximum bytes kept on disk, 0 means unlimited
+	SegmentSize int64 // Size at which a new segment file is started
+}
+
+// Stats describes the backlog held by a spool
+type Stats struct {
+	Records  int   // Records not yet acknowledged
+	Bytes    int64 // Bytes on disk not yet acknowledged, including framing
+	Segments int   // Segment files on disk
+	Corrupt  int   // Records skipped since Open because they failed their checksum or framing
+}
+
+type segment struct {
+	id      uint64
+	size    int64
+	records int // Records not yet acknowledged
+}
+
+// Spool is a durable FIFO queue. It is safe for concurrent use.
+type Spool struct {
+	mu   sync.Mutex
+	dir  string
+	opts Options
+
+	segments []segment
+	writer   *os.File // Open handle on the last segment
+	reader   *os.File // Open handle on the first segment
+
+	// Cursor: the next record to read is at readOff in segments[0]
+	readOff int64
+	// Size of the record returned by the last Peek, 0 if none is pending
+	peeked int64
+
+	records int
+	corrupt int
+	closed  bool
+}
+
+// Open opens or creates a spool in dir, recovering any existing segments
+func Open(dir string, opts Options) (*Spool, error) {
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = defaultSegmentSize
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	s := &Spool{dir: dir, opts: opts}
+
+	if err := s.recover(); err != nil {
+		s.closeFiles()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// recover loads the segment list and cursor, dropping consumed segments and
+// truncating corrupt tails
+func (s *Spool) recover() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	var ids []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	cursorID, cursorOff, cursorOK := s.readCursor()
+
+	for _, id := range ids {
+		// Segments before the cursor have been fully acknowledged
+		if cursorOK && id < cursorID {
+			os.Remove(s.segmentPath(id))
+			continue
+		}
+
+		start := int64(0)
+		if cursorOK && id == cursorID {
+			start = cursorOff
+		}
+
+		size, records, err := s.scanSegment(id, start)
+		if err != nil {
+			return err
+		}
+
+		if len(s.segments) == 0 {
+			s.readOff = min(start, size)
+		}
+		s.segments = append(s.segments, segment{id: id, size: size, records: records})
+		s.records += records
+	}
+
+	return nil
+}
+
+// scanSegment validates the framing of every record in a segment, truncating a
+// torn or corrupt record at the end of the file, and counts the records after
+// start. Corrupt records followed by others are kept for Peek to skip.
+func (s *Spool) scanSegment(id uint64, start int64) (int64, int, error) {
+	path := s.segmentPath(id)
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0o644)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to stat spool segment: %w", err)
+	}
+
+	var offset int64
+	records := 0
+
+	for {
+		size, valid, err := readRecordSize(f, offset, info.Size())
+		if err != nil || (!valid && offset+size == info.Size()) {
+			break
+		}
+		if offset >= start {
+			records++
+		}
+		offset += size
+	}
+
+	if info.Size() != offset {
+		if err := f.Truncate(offset); err != nil {
+			return 0, 0, fmt.Errorf("failed to truncate corrupt spool segment: %w", err)
+		}
+		if err := f.Sync(); err != nil {
+			return 0, 0, fmt.Errorf("failed to sync spool segment: %w", err)
+		}
+	}
+
+	return offset, records, nil
+}
+
+// Append durably adds a payload to the end of the spool
+func (s *Spool) Append(payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	recordSize := int64(headerSize + len(payload))
+	if s.opts.MaxBytes > 0 && s.diskBytes()-s.readOff+recordSize > s.opts.MaxBytes {
+		return ErrFull
+	}
+
+	if err := s.prepareWriter(recordSize); err != nil {
+		return err
+	}
+
+	record := make([]byte, recordSize)
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.Checksum(payload, crcTable))
+	copy(record[headerSize:], payload)
+
+	last := &s.segments[len(s.segments)-1]
+	if _, err := s.writer.WriteAt(record, last.size); err != nil {
+		return fmt.Errorf("failed to write spool record: %w", err)
+	}
+	if err := s.writer.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spool segment: %w", err)
+	}
+
+	last.size += recordSize
+	last.records++
+	s.records++
+
+	return nil
+}
+
+// prepareWriter makes sure the last segment is open and has room for the record
+func (s *Spool) prepareWriter(recordSize int64) error {
+	if len(s.segments) > 0 {
+		last := s.segments[len(s.segments)-1]
+		if last.size == 0 || last.size+recordSize <= s.opts.SegmentSize {
+			if s.writer == nil {
+				f, err := os.OpenFile(s.segmentPath(last.id), os.O_RDWR, 0o644)
+				if err != nil {
+					return fmt.Errorf("failed to open spool segment: %w", err)
+				}
+				s.writer = f
+			}
+			return nil
+		}
+	}
+
+	var id uint64
+	if len(s.segments) > 0 {
+		id = s.segments[len(s.segments)-1].id + 1
+	} else if cursorID, _, ok := s.readCursor(); ok {
+		id = cursorID + 1
+	}
+
+	f, err := os.OpenFile(s.segmentPath(id), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create spool segment: %w", err)
+	}
+	if err := syncDir(s.dir); err != nil {
+		f.Close()
+		return err
+	}
+
+	if s.writer != nil {
+		s.writer.Close()
+	}
+	s.writer = f
+	s.segments = append(s.segments, segment{id: id})
+
+	return nil
+}
+
+// Peek returns the oldest unacknowledged payload. The second result is false if
+// the spool is empty. Calling Peek again without Ack returns the same payload.
+func (s *Spool) Peek() ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, false, ErrClosed
+	}
+
+	if s.records == 0 || len(s.segments) == 0 {
+		return nil, false, nil
+	}
+
+	// Skip fully consumed segments
+	for s.readOff >= s.segments[0].size && len(s.segments) > 1 {
+		if err := s.dropFirstSegment(); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if err := s.openReader(); err != nil {
+		return nil, false, err
+	}
+
+	for {
+		first := s.segments[0]
+
+		var header [headerSize]byte
+		if _, err := s.reader.ReadAt(header[:], s.readOff); err != nil {
+			return nil, false, fmt.Errorf("failed to read spool record: %w", err)
+		}
+
+		// A length running past the segment means the framing is lost, so the
+		// rest of the segment cannot be read
+		length := int64(binary.BigEndian.Uint32(header[0:4]))
+		if s.readOff+headerSize+length > first.size {
+			if err := s.skip(first.size-s.readOff, first.records); err != nil {
+				return nil, false, err
+			}
+			if s.records == 0 {
+				return nil, false, nil
+			}
+			continue
+		}
+
+		payload := make([]byte, length)
+		if _, err := s.reader.ReadAt(payload, s.readOff+headerSize); err != nil {
+			return nil, false, fmt.Errorf("failed to read spool record: %w", err)
+		}
+
+		if crc32.Checksum(payload, crcTable) != binary.BigEndian.Uint32(header[4:8]) {
+			if err := s.skip(headerSize+length, 1); err != nil {
+				return nil, false, err
+			}
+			if s.records == 0 {
+				return nil, false, nil
+			}
+			continue
+		}
+
+		s.peeked = headerSize + length
+
+		return payload, true, nil
+	}
+}
+
+// skip drops corrupt records spanning size bytes from the read position and
+// moves to the next segment if the first one is exhausted
+func (s *Spool) skip(size int64, records int) error {
+	s.readOff += size
+	s.segments[0].records -= records
+	s.records -= records
+	s.corrupt += records
+
+	if err := s.writeCursor(s.segments[0].id, s.readOff); err != nil {
+		return err
+	}
+
+	if s.readOff < s.segments[0].size || len(s.segments) == 1 {
+		return nil
+	}
+	if err := s.dropFirstSegment(); err != nil {
+		return err
+	}
+	return s.openReader()
+}
+
+// Ack acknowledges the payload returned by the last Peek, removing it from the spool
+func (s *Spool) Ack() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	if s.peeked == 0 {
+		return errors.New("spool: Ack called without a pending Peek")
+	}
+
+	s.readOff += s.peeked
+	s.peeked = 0
+	s.segments[0].records--
+	s.records--
+
+	if err := s.writeCursor(s.segments[0].id, s.readOff); err != nil {
+		return err
+	}
+
+	if s.readOff >= s.segments[0].size {
+		return s.dropFirstSegment()
+	}
+
+	return nil
+}
+
+// Stats returns the current backlog
+func (s *Spool) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return Stats{
+		Records:  s.records,
+		Bytes:    s.diskBytes() - s.readOff,
+		Segments: len(s.segments),
+		Corrupt:  s.corrupt,
+	}
+}
+
+// Close releases the open segment files
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	return s.closeFiles()
+}
+
+// openReader opens the first segment for reading if it is not open yet
+func (s *Spool) openReader() error {
+	if s.reader != nil {
+		return nil
+	}
+
+	f, err := os.Open(s.segmentPath(s.segments[0].id))
+	if err != nil {
+		return fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	s.reader = f
+	return nil
+}
+
+// dropFirstSegment deletes the first segment once all its records are acknowledged
+func (s *Spool) dropFirstSegment() error {
+	first := s.segments[0]
+
+	if s.reader != nil {
+		s.reader.Close()
+		s.reader = nil
+	}
+	if len(s.segments) == 1 && s.writer != nil {
+		s.writer.Close()
+		s.writer = nil
+	}
+
+	// Point the cursor past the segment before removing it so a crash in between
+	// never replays acknowledged records
+	if err := s.writeCursor(first.id, first.size); err != nil {
+		return err
+	}
+	if err := os.Remove(s.segmentPath(first.id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove spool segment: %w", err)
+	}
+
+	s.segments = s.segments[1:]
+	s.readOff = 0
+
+	return nil
+}
+
+func (s *Spool) diskBytes() int64 {
+	var total int64
+	for _, seg := range s.segments {
+		total += seg.size
+	}
+	return total
+}
+
+func (s *Spool) closeFiles() error {
+	var err error
+	if s.reader != nil {
+		err = s.reader.Close()
+		s.reader = nil
+	}
+	if s.writer != nil {
+		if closeErr := s.writer.Close(); err == nil {
+			err = closeErr
+		}
+		s.writer = nil
+	}
+	return err
+}
+
+func (s *Spool) segmentPath(id uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", id, segmentExt))
+}
+
+// readCursor loads the persisted cursor, reporting false if it is missing or corrupt
+func (s *Spool) readCursor() (uint64, int64, bool) {
+	data, err := os.ReadFile(filepath.Join(s.dir, cursorFile))
+	if err != nil || len(data) != 20 {
+		return 0, 0, false
+	}
+
+	if crc32.Checksum(data[:16], crcTable) != binary.BigEndian.Uint32(data[16:20]) {
+		return 0, 0, false
+	}
+
+	return binary.BigEndian.Uint64(data[0:8]), int64(binary.BigEndian.Uint64(data[8:16])), true
+}
+
+// writeCursor atomically persists the cursor
+func (s *Spool) writeCursor(id uint64, offset int64) error {
+	var data [20]byte
+	binary.BigEndian.PutUint64(data[0:8], id)
+	binary.BigEndian.PutUint64(data[8:16], uint64(offset))
+	binary.BigEndian.PutUint32(data[16:20], crc32.Checksum(data[:16], crcTable))
+
+	path := filepath.Join(s.dir, cursorFile)
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write spool cursor: %w", err)
+	}
+	if _, err := f.Write(data[:]); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write spool cursor: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync spool cursor: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write spool cursor: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write spool cursor: %w", err)
+	}
+
+	return syncDir(s.dir)
+}
+
+// readRecordSize returns the framed size of the record at offset in a file of
+// fileSize bytes and whether its checksum matches. An error means the record is
+// not framed within the file.
+func readRecordSize(f *os.File, offset int64, fileSize int64) (int64, bool, error) {
+	var header [headerSize]byte
+	if _, err := f.ReadAt(header[:], offset); err != nil {
+		return 0, false, err
+	}
+
+	// A corrupt length must not cause a huge allocation
+	length := int64(binary.BigEndian.Uint32(header[0:4]))
+	if offset+headerSize+length > fileSize {
+		return 0, false, io.ErrUnexpectedEOF
+	}
+
+	payload := make([]byte, length)
+	if _, err := f.ReadAt(payload, offset+headerSize); err != nil {
+		return 0, false, err
+	}
+
+	valid := crc32.Checksum(payload, crcTable) == binary.BigEndian.Uint32(header[4:8])
+	return headerSize + length, valid, nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open spool directory: %w", err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spool directory: %w", err)
+	}
+	return nil
+}
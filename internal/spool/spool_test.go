@@ -0,0 +1,330 @@
+package spool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func drain(t *testing.T, s *Spool) []string {
+	t.Helper()
+
+	var payloads []string
+	for {
+		payload, ok, err := s.Peek()
+		if err != nil {
+			t.Fatalf("Peek() error: %v", err)
+		}
+		if !ok {
+			return payloads
+		}
+		payloads = append(payloads, string(payload))
+		if err := s.Ack(); err != nil {
+			t.Fatalf("Ack() error: %v", err)
+		}
+	}
+}
+
+func TestSpool_FIFOAcrossSegments(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{SegmentSize: 32})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer s.Close()
+
+	var expected []string
+	for i := 0; i < 10; i++ {
+		payload := fmt.Sprintf("payload-%d", i)
+		expected = append(expected, payload)
+		if err := s.Append([]byte(payload)); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+
+	if stats := s.Stats(); stats.Records != 10 || stats.Segments < 2 {
+		t.Fatalf("Stats() = %+v, want 10 records over several segments", stats)
+	}
+
+	got := drain(t, s)
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("drained %v, want %v", got, expected)
+	}
+
+	if stats := s.Stats(); stats.Records != 0 || stats.Bytes != 0 {
+		t.Errorf("Stats() after drain = %+v, want empty", stats)
+	}
+}
+
+func TestSpool_RecoversCursorAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, Options{SegmentSize: 64})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := s.Append([]byte(fmt.Sprintf("record-%d", i))); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+
+	// Acknowledge the first two records, then simulate a restart
+	for i := 0; i < 2; i++ {
+		if _, _, err := s.Peek(); err != nil {
+			t.Fatalf("Peek() error: %v", err)
+		}
+		if err := s.Ack(); err != nil {
+			t.Fatalf("Ack() error: %v", err)
+		}
+	}
+	s.Close()
+
+	s, err = Open(dir, Options{SegmentSize: 64})
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	defer s.Close()
+
+	got := drain(t, s)
+	expected := []string{"record-2", "record-3", "record-4"}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("drained %v, want %v", got, expected)
+	}
+
+	if err := s.Append([]byte("after-drain")); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	if got := drain(t, s); len(got) != 1 || got[0] != "after-drain" {
+		t.Errorf("drained %v, want [after-drain]", got)
+	}
+}
+
+func TestSpool_TruncatesTornRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	if err := s.Append([]byte("complete")); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	s.Close()
+
+	// Simulate a crash part way through writing a second record
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	f, err := os.OpenFile(segments[0], os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("failed to open segment: %v", err)
+	}
+	f.Write([]byte{0, 0, 0, 42, 1, 2})
+	f.Close()
+
+	s, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	defer s.Close()
+
+	if got := drain(t, s); len(got) != 1 || got[0] != "complete" {
+		t.Errorf("drained %v, want [complete]", got)
+	}
+}
+
+func TestSpool_MaxBytes(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{MaxBytes: 20})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.Append([]byte("0123456789")); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	if err := s.Append([]byte("0123456789")); !errors.Is(err, ErrFull) {
+		t.Errorf("Append() error = %v, want ErrFull", err)
+	}
+}
+
+func TestSpool_SkipsCorruptRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer s.Close()
+
+	for _, payload := range []string{"first", "second", "third"} {
+		if err := s.Append([]byte(payload)); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+
+	// Flip a byte in the payload of the second record
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	f, err := os.OpenFile(segments[0], os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("failed to open segment: %v", err)
+	}
+	f.WriteAt([]byte{'X'}, headerSize+int64(len("first"))+headerSize)
+	f.Close()
+
+	got := drain(t, s)
+	if fmt.Sprint(got) != fmt.Sprint([]string{"first", "third"}) {
+		t.Errorf("drained %v, want [first third]", got)
+	}
+	if stats := s.Stats(); stats.Corrupt != 1 || stats.Records != 0 {
+		t.Errorf("Stats() = %+v, want 1 corrupt and no records", stats)
+	}
+}
+
+func TestSpool_SkipsOversizedLength(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, Options{SegmentSize: 64})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 6; i++ {
+		if err := s.Append([]byte(fmt.Sprintf("record-%d", i))); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+	if stats := s.Stats(); stats.Segments < 2 {
+		t.Fatalf("Stats() = %+v, want several segments", stats)
+	}
+
+	// A length far beyond the segment must not be trusted
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	f, err := os.OpenFile(segments[0], os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("failed to open segment: %v", err)
+	}
+	f.WriteAt([]byte{0xff, 0xff, 0xff, 0xff}, 0)
+	f.Close()
+
+	got := drain(t, s)
+	if len(got) == 0 || got[len(got)-1] != "record-5" {
+		t.Errorf("drained %v, want the records of later segments", got)
+	}
+	if stats := s.Stats(); stats.Corrupt+len(got) != 6 {
+		t.Errorf("Stats() = %+v with %d drained, want every record accounted for", stats, len(got))
+	}
+}
+
+func TestSpool_TruncatesOversizedLengthOnOpen(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	if err := s.Append([]byte("complete")); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	s.Close()
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	f, err := os.OpenFile(segments[0], os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("failed to open segment: %v", err)
+	}
+	f.Write([]byte{0xff, 0xff, 0xff, 0xf0, 0, 0, 0, 0, 1, 2, 3})
+	f.Close()
+
+	s, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	defer s.Close()
+
+	if got := drain(t, s); len(got) != 1 || got[0] != "complete" {
+		t.Errorf("drained %v, want [complete]", got)
+	}
+}
+
+func TestSpool_KeepsRecordsAfterCorruptionOnReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	for _, payload := range []string{"first", "second", "third"} {
+		if err := s.Append([]byte(payload)); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+	s.Close()
+
+	// Flip a byte in the payload of the middle record
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	f, err := os.OpenFile(segments[0], os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("failed to open segment: %v", err)
+	}
+	f.WriteAt([]byte{'X'}, headerSize+int64(len("first"))+headerSize)
+	f.Close()
+
+	s, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	defer s.Close()
+
+	if stats := s.Stats(); stats.Records != 3 {
+		t.Fatalf("Stats() = %+v, want 3 records", stats)
+	}
+
+	got := drain(t, s)
+	if fmt.Sprint(got) != fmt.Sprint([]string{"first", "third"}) {
+		t.Errorf("drained %v, want [first third]", got)
+	}
+	if stats := s.Stats(); stats.Corrupt != 1 {
+		t.Errorf("Stats() = %+v, want 1 corrupt", stats)
+	}
+}
+
+func TestSpool_TruncatesCorruptLastRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	for _, payload := range []string{"first", "last"} {
+		if err := s.Append([]byte(payload)); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+	s.Close()
+
+	// A checksum mismatch at the end of the file is a torn write
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	info, _ := os.Stat(segments[0])
+	f, err := os.OpenFile(segments[0], os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("failed to open segment: %v", err)
+	}
+	f.WriteAt([]byte{0}, info.Size()-1)
+	f.Close()
+
+	s, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	defer s.Close()
+
+	if stats := s.Stats(); stats.Records != 1 {
+		t.Errorf("Stats() = %+v, want 1 record", stats)
+	}
+	if got := drain(t, s); len(got) != 1 || got[0] != "first" {
+		t.Errorf("drained %v, want [first]", got)
+	}
+}
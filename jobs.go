@@ -0,0 +1,69 @@
+package tinybird
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Job statuses reported by the Jobs API.
+const (
+	JobStatusWaiting   = "waiting"
+	JobStatusWorking   = "working"
+	JobStatusDone      = "done"
+	JobStatusError     = "error"
+	JobStatusCancelled = "cancelled"
+)
+
+// DefaultJobPollInterval is the interval used by WaitForJob when none is given.
+const DefaultJobPollInterval = 2 * time.Second
+
+// ErrJobFailed is returned by WaitForJob when a job finishes with an error or is cancelled.
+var ErrJobFailed = errors.New("job failed")
+
+// Done reports whether the job has reached a final status
+func (j *Job) Done() bool {
+	return j.Status == JobStatusDone || j.Status == JobStatusError || j.Status == JobStatusCancelled
+}
+
+func (c *ClientImpl) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	var job Job
+
+	err := c.httpClient.Get(ctx, c.apiURL("jobs/"+url.PathEscape(jobID)), nil, &job)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (c *ClientImpl) WaitForJob(ctx context.Context, jobID string, pollInterval time.Duration) (*Job, error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultJobPollInterval
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := c.GetJob(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+
+		if job.Done() {
+			if job.Status != JobStatusDone {
+				return job, fmt.Errorf("%w: job %s finished with status %s: %s", ErrJobFailed, job.ID, job.Status, job.Error)
+			}
+			return job, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package tinybird
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// populateJobKind is the job kind of materialized view populations
+const populateJobKind = "populateview"
+
+func (c *ClientImpl) CreateMaterialization(ctx context.Context, pipeName string, spec MaterializationSpec) (*Materialization, error) {
+	nodeParams := map[string]string{"name": spec.Node}
+	if spec.Description != "" {
+		nodeParams["description"] = spec.Description
+	}
+
+	var node PipeNode
+
+	nodeUrl := withQueryParams(c.apiURL("pipes/"+url.PathEscape(pipeName)+"/nodes"), nodeParams)
+	if err := c.httpClient.PostRaw(ctx, nodeUrl, []byte(spec.SQL), "text/plain", "", &node); err != nil {
+		return nil, err
+	}
+
+	params := map[string]string{"datasource": spec.Datasource}
+	if spec.Populate != nil {
+		params["populate"] = "true"
+		for k, v := range spec.Populate.params() {
+			params[k] = v
+		}
+	}
+
+	var response struct {
+		PipeNode
+		Job *jobResponse `json:"job"`
+	}
+
+	reqUrl := withQueryParams(c.apiURL("pipes/"+url.PathEscape(pipeName)+"/nodes/"+url.PathEscape(spec.Node)+"/materialization"), params)
+	if err := c.httpClient.PostRaw(ctx, reqUrl, nil, "", "", &response); err != nil {
+		return nil, err
+	}
+
+	materialization := &Materialization{Node: response.PipeNode}
+	if response.Job != nil {
+		materialization.Job = response.Job.job()
+	}
+	if materialization.Node.Name == "" {
+		materialization.Node = node
+	}
+
+	if materialization.Job == nil || spec.Populate == nil || !spec.Populate.Wait {
+		return materialization, nil
+	}
+
+	job, err := c.WaitForJob(ctx, materialization.Job.ID, spec.Populate.PollInterval)
+	materialization.Job = job
+	return materialization, err
+}
+
+func (c *ClientImpl) PopulateMaterialization(ctx context.Context, pipeName string, nodeName string, options *PopulateOptions) (*Job, error) {
+	if options == nil {
+		options = &PopulateOptions{}
+	}
+
+	var response jobResponse
+
+	reqUrl := withQueryParams(c.apiURL("pipes/"+url.PathEscape(pipeName)+"/nodes/"+url.PathEscape(nodeName)+"/population"), options.params())
+	if err := c.httpClient.PostRaw(ctx, reqUrl, nil, "", "", &response); err != nil {
+		return nil, err
+	}
+
+	job := response.job()
+	if !options.Wait {
+		return job, nil
+	}
+
+	return c.WaitForJob(ctx, job.ID, options.PollInterval)
+}
+
+func (c *ClientImpl) GetMaterializationStatus(ctx context.Context, pipeName string, nodeName string) (*MaterializationStatus, error) {
+	var node PipeNode
+
+	err := c.httpClient.Get(ctx, c.apiURL("pipes/"+url.PathEscape(pipeName)+"/nodes/"+url.PathEscape(nodeName)), nil, &node)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MaterializationStatus{
+		Node:         node,
+		Materialized: node.Materialized != "",
+		Datasource:   node.Materialized,
+	}
+
+	if !status.Materialized {
+		return status, nil
+	}
+
+	// Jobs are listed per pipe, so match the populate jobs of this node by the
+	// datasource they write to
+	jobs, err := listJobs[Job](ctx, c, populateJobKind, pipeName, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i, job := range jobs {
+		if job.Datasource != nil && (job.Datasource.ID == node.Materialized || job.Datasource.Name == node.Materialized) {
+			status.LastPopulate = &jobs[i]
+			break
+		}
+	}
+
+	return status, nil
+}
+
+// params returns the query parameters controlling a population
+func (o *PopulateOptions) params() map[string]string {
+	params := map[string]string{}
+	if o.Subset > 0 {
+		params["populate_subset"] = strconv.FormatFloat(o.Subset, 'f', -1, 64)
+	}
+	if o.Condition != "" {
+		params["populate_condition"] = o.Condition
+	}
+	if o.Truncate {
+		params["truncate"] = "true"
+	}
+	return params
+}
@@ -0,0 +1,113 @@
+package tinybird
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateMaterialization_PopulatesAndWaits(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockClient.On("PostRaw",
+		mock.Anything,
+		"https://api.tinybird.co/v0/pipes/sales/nodes?name=sales_mv",
+		[]byte("SELECT day, sum(amount) AS total FROM sales GROUP BY day"),
+		"text/plain",
+		"",
+		mock.AnythingOfType("*tinybird.PipeNode"),
+	).Return(nil)
+
+	mockClient.On("PostRaw",
+		mock.Anything,
+		"https://api.tinybird.co/v0/pipes/sales/nodes/sales_mv/materialization?datasource=sales_by_day&populate=true&populate_condition=day+%3E%3D+%272024-01-01%27&populate_subset=0.1",
+		[]byte(nil),
+		"",
+		"",
+		mock.Anything,
+	).Return(nil).Run(func(args mock.Arguments) {
+		response := args.Get(5).(*struct {
+			PipeNode
+			Job *jobResponse `json:"job"`
+		})
+		response.Name = "sales_mv"
+		response.Materialized = "t_123"
+		response.Job = &jobResponse{Job: Job{JobID: "job-1", Status: JobStatusWaiting}}
+	})
+
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/jobs/job-1",
+		mock.Anything,
+		mock.AnythingOfType("*tinybird.Job"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(3).(*Job) = Job{ID: "job-1", Kind: populateJobKind, Status: JobStatusDone}
+	})
+
+	materialization, err := client.CreateMaterialization(context.Background(), "sales", MaterializationSpec{
+		Node:       "sales_mv",
+		SQL:        "SELECT day, sum(amount) AS total FROM sales GROUP BY day",
+		Datasource: "sales_by_day",
+		Populate: &PopulateOptions{
+			Subset:    0.1,
+			Condition: "day >= '2024-01-01'",
+			Wait:      true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if materialization.Node.Materialized != "t_123" {
+		t.Errorf("Materialized = %q, want t_123", materialization.Node.Materialized)
+	}
+	if materialization.Job == nil || materialization.Job.Status != JobStatusDone {
+		t.Errorf("Job = %+v, want a finished populate job", materialization.Job)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetMaterializationStatus(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/pipes/sales/nodes/sales_mv",
+		mock.Anything,
+		mock.AnythingOfType("*tinybird.PipeNode"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(3).(*PipeNode) = PipeNode{Name: "sales_mv", Materialized: "t_123"}
+	})
+
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/jobs",
+		map[string]string{"kind": populateJobKind, "pipe_name": "sales"},
+		mock.Anything,
+	).Return(nil).Run(func(args mock.Arguments) {
+		response := args.Get(3).(*struct {
+			Jobs []Job `json:"jobs"`
+		})
+		response.Jobs = []Job{
+			{ID: "job-3", Status: JobStatusWorking, Datasource: &JobDatasource{ID: "t_456", Name: "returns"}},
+			{ID: "job-2", Status: JobStatusWorking, Datasource: &JobDatasource{ID: "t_123", Name: "sales_daily"}},
+			{ID: "job-1", Status: JobStatusDone, Datasource: &JobDatasource{ID: "t_123", Name: "sales_daily"}},
+		}
+	})
+
+	status, err := client.GetMaterializationStatus(context.Background(), "sales", "sales_mv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !status.Materialized || status.Datasource != "t_123" {
+		t.Errorf("status = %+v, want materialized into t_123", status)
+	}
+	if status.LastPopulate == nil || status.LastPopulate.ID != "job-2" {
+		t.Errorf("LastPopulate = %+v, want job-2, the latest job of the node's datasource", status.LastPopulate)
+	}
+}
@@ -2,6 +2,7 @@ package tinybird
 
 import (
 	"context"
+	"io"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -44,7 +45,17 @@ func (m *MockHttpClient) PostRaw(ctx context.Context, url string, body []byte, c
 	return args.Error(0)
 }
 
+func (m *MockHttpClient) PostStream(ctx context.Context, url string, getBody func() (io.ReadCloser, error), contentType string, contentEncoding string, result interface{}) error {
+	args := m.Called(ctx, url, getBody, contentType, contentEncoding, result)
+	return args.Error(0)
+}
+
 func (m *MockHttpClient) Put(ctx context.Context, url string, body interface{}, result interface{}) error {
 	args := m.Called(ctx, url, body, result)
 	return args.Error(0)
 }
+
+func (m *MockHttpClient) PutRaw(ctx context.Context, url string, body []byte, contentType string, contentEncoding string, result interface{}) error {
+	args := m.Called(ctx, url, body, contentType, contentEncoding, result)
+	return args.Error(0)
+}
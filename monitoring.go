@@ -0,0 +1,320 @@
+package tinybird
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMonitoringLimit is the number of rows returned by monitoring queries when no limit is given.
+const DefaultMonitoringLimit = 1000
+
+// Results reported in DatasourceOperation.Result
+const (
+	OperationResultOK    = "ok"
+	OperationResultError = "error"
+)
+
+// MonitoringClient queries Tinybird's service datasources to monitor endpoint
+// latency, errors and ingestion. Every method runs a SQL query through Query.
+type MonitoringClient interface {
+	// PipeStats returns request counts, error rates and latency percentiles per
+	// pipe from tinybird.pipe_stats_rt, slowest p95 first.
+	//
+	// options: Optional time range, pipe names and limit.
+	PipeStats(ctx context.Context, options *MonitoringOptions) ([]PipeStats, error)
+	// PipeRequests returns individual pipe requests from tinybird.pipe_stats_rt, newest first.
+	//
+	// options: Optional time range, pipe names, errors only and limit.
+	PipeRequests(ctx context.Context, options *MonitoringOptions) ([]PipeRequest, error)
+	// IngestOperations returns datasource operations such as appends, imports and
+	// deletes from tinybird.datasources_ops_log, newest first.
+	//
+	// options: Optional time range, datasource names, errors only and limit.
+	IngestOperations(ctx context.Context, options *MonitoringOptions) ([]DatasourceOperation, error)
+	// BIQueries returns queries sent through the ClickHouse interface from
+	// tinybird.bi_stats_rt, newest first.
+	//
+	// options: Optional time range, errors only and limit. Names is ignored.
+	BIQueries(ctx context.Context, options *MonitoringOptions) ([]BIQuery, error)
+}
+
+type monitoringClient struct {
+	client *ClientImpl
+}
+
+func (c *ClientImpl) Monitoring() MonitoringClient {
+	return &monitoringClient{client: c}
+}
+
+func (m *monitoringClient) PipeStats(ctx context.Context, options *MonitoringOptions) ([]PipeStats, error) {
+	sql := `SELECT
+		pipe_name,
+		toFloat64(count()) AS requests,
+		toFloat64(countIf(error = 1)) AS errors,
+		toFloat64(avg(duration)) AS avg_duration,
+		toFloat64(quantile(0.5)(duration)) AS p50_duration,
+		toFloat64(quantile(0.95)(duration)) AS p95_duration,
+		toFloat64(quantile(0.99)(duration)) AS p99_duration,
+		toFloat64(sum(read_bytes)) AS read_bytes,
+		toFloat64(sum(read_rows)) AS read_rows
+	FROM tinybird.pipe_stats_rt` +
+		monitoringWhere(options, "start_datetime", "pipe_name", "error = 1") +
+		" GROUP BY pipe_name ORDER BY p95_duration DESC" +
+		monitoringLimit(options)
+
+	rows, err := m.query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]PipeStats, 0, len(rows))
+	for _, row := range rows {
+		s := PipeStats{
+			PipeName:    rowString(row, "pipe_name"),
+			Requests:    rowInt(row, "requests"),
+			Errors:      rowInt(row, "errors"),
+			AvgDuration: rowSeconds(row, "avg_duration"),
+			P50Duration: rowSeconds(row, "p50_duration"),
+			P95Duration: rowSeconds(row, "p95_duration"),
+			P99Duration: rowSeconds(row, "p99_duration"),
+			ReadBytes:   rowInt(row, "read_bytes"),
+			ReadRows:    rowInt(row, "read_rows"),
+		}
+		if s.Requests > 0 {
+			s.ErrorRate = float64(s.Errors) / float64(s.Requests)
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, nil
+}
+
+func (m *monitoringClient) PipeRequests(ctx context.Context, options *MonitoringOptions) ([]PipeRequest, error) {
+	sql := `SELECT
+		toString(start_datetime) AS start_datetime,
+		request_id,
+		pipe_name,
+		toFloat64(duration) AS duration,
+		toFloat64(status_code) AS status_code,
+		error = 1 AS failed,
+		toFloat64(read_bytes) AS read_bytes,
+		toFloat64(read_rows) AS read_rows,
+		toFloat64(result_rows) AS result_rows,
+		url
+	FROM tinybird.pipe_stats_rt` +
+		monitoringWhere(options, "start_datetime", "pipe_name", "error = 1") +
+		" ORDER BY start_datetime DESC" +
+		monitoringLimit(options)
+
+	rows, err := m.query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]PipeRequest, 0, len(rows))
+	for _, row := range rows {
+		startTime, err := rowTime(row, "start_datetime")
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, PipeRequest{
+			StartTime:  startTime,
+			RequestID:  rowString(row, "request_id"),
+			PipeName:   rowString(row, "pipe_name"),
+			Duration:   rowSeconds(row, "duration"),
+			StatusCode: int(rowInt(row, "status_code")),
+			Error:      rowBool(row, "failed"),
+			ReadBytes:  rowInt(row, "read_bytes"),
+			ReadRows:   rowInt(row, "read_rows"),
+			ResultRows: rowInt(row, "result_rows"),
+			URL:        rowString(row, "url"),
+		})
+	}
+
+	return requests, nil
+}
+
+func (m *monitoringClient) IngestOperations(ctx context.Context, options *MonitoringOptions) ([]DatasourceOperation, error) {
+	sql := `SELECT
+		toString(timestamp) AS timestamp,
+		event_type,
+		datasource_id,
+		datasource_name,
+		result,
+		toFloat64(elapsed_time) AS elapsed_time,
+		ifNull(error, '') AS error,
+		toFloat64(ifNull(rows, 0)) AS rows,
+		toFloat64(ifNull(rows_quarantine, 0)) AS rows_quarantine,
+		ifNull(job_id, '') AS job_id,
+		ifNull(import_id, '') AS import_id
+	FROM tinybird.datasources_ops_log` +
+		monitoringWhere(options, "timestamp", "datasource_name", "result = "+quoteString(OperationResultError)) +
+		" ORDER BY timestamp DESC" +
+		monitoringLimit(options)
+
+	rows, err := m.query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+
+	operations := make([]DatasourceOperation, 0, len(rows))
+	for _, row := range rows {
+		timestamp, err := rowTime(row, "timestamp")
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, DatasourceOperation{
+			Timestamp:      timestamp,
+			EventType:      rowString(row, "event_type"),
+			DatasourceID:   rowString(row, "datasource_id"),
+			DatasourceName: rowString(row, "datasource_name"),
+			Result:         rowString(row, "result"),
+			ElapsedTime:    rowSeconds(row, "elapsed_time"),
+			Error:          rowString(row, "error"),
+			Rows:           rowInt(row, "rows"),
+			RowsQuarantine: rowInt(row, "rows_quarantine"),
+			JobID:          rowString(row, "job_id"),
+			ImportID:       rowString(row, "import_id"),
+		})
+	}
+
+	return operations, nil
+}
+
+func (m *monitoringClient) BIQueries(ctx context.Context, options *MonitoringOptions) ([]BIQuery, error) {
+	if options != nil && len(options.Names) > 0 {
+		copied := *options
+		copied.Names = nil
+		options = &copied
+	}
+
+	sql := `SELECT
+		toString(start_datetime) AS start_datetime,
+		query,
+		query_normalized,
+		toFloat64(error_code) AS error_code,
+		error,
+		toFloat64(duration) AS duration_ms,
+		toFloat64(read_rows) AS read_rows,
+		toFloat64(read_bytes) AS read_bytes,
+		toFloat64(result_rows) AS result_rows,
+		toFloat64(result_bytes) AS result_bytes
+	FROM tinybird.bi_stats_rt` +
+		monitoringWhere(options, "start_datetime", "", "error_code != 0") +
+		" ORDER BY start_datetime DESC" +
+		monitoringLimit(options)
+
+	rows, err := m.query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+
+	queries := make([]BIQuery, 0, len(rows))
+	for _, row := range rows {
+		startTime, err := rowTime(row, "start_datetime")
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, BIQuery{
+			StartTime:       startTime,
+			Query:           rowString(row, "query"),
+			QueryNormalized: rowString(row, "query_normalized"),
+			ErrorCode:       int(rowInt(row, "error_code")),
+			Error:           rowString(row, "error"),
+			Duration:        time.Duration(rowFloat(row, "duration_ms") * float64(time.Millisecond)),
+			ReadRows:        rowInt(row, "read_rows"),
+			ReadBytes:       rowInt(row, "read_bytes"),
+			ResultRows:      rowInt(row, "result_rows"),
+			ResultBytes:     rowInt(row, "result_bytes"),
+		})
+	}
+
+	return queries, nil
+}
+
+func (m *monitoringClient) query(ctx context.Context, sql string) ([]map[string]interface{}, error) {
+	response, err := m.client.Query(ctx, sql, nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// monitoringWhere builds the WHERE clause of a service datasource query. The
+// name filter is skipped when nameColumn is empty.
+func monitoringWhere(options *MonitoringOptions, timeColumn string, nameColumn string, errorCondition string) string {
+	if options == nil {
+		return " WHERE 1"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(" WHERE 1")
+	sb.WriteString(timeRange(timeColumn, options.Since, options.Until))
+	if nameColumn != "" && len(options.Names) > 0 {
+		names := make([]string, len(options.Names))
+		for i, name := range options.Names {
+			names[i] = quoteString(name)
+		}
+		fmt.Fprintf(&sb, " AND %s IN (%s)", nameColumn, strings.Join(names, ", "))
+	}
+	if options.ErrorsOnly {
+		fmt.Fprintf(&sb, " AND %s", errorCondition)
+	}
+	return sb.String()
+}
+
+func monitoringLimit(options *MonitoringOptions) string {
+	limit := DefaultMonitoringLimit
+	if options != nil && options.Limit > 0 {
+		limit = options.Limit
+	}
+	return fmt.Sprintf(" LIMIT %d", limit)
+}
+
+func rowString(row map[string]interface{}, name string) string {
+	if row[name] == nil {
+		return ""
+	}
+	return stringify(row[name])
+}
+
+// rowFloat reads a numeric column, which ClickHouse may quote when it is a 64-bit integer
+func rowFloat(row map[string]interface{}, name string) float64 {
+	switch v := row[name].(type) {
+	case float64:
+		return v
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	default:
+		return 0
+	}
+}
+
+func rowInt(row map[string]interface{}, name string) int64 {
+	return int64(rowFloat(row, name))
+}
+
+func rowBool(row map[string]interface{}, name string) bool {
+	if b, ok := row[name].(bool); ok {
+		return b
+	}
+	return rowFloat(row, name) != 0
+}
+
+// rowSeconds reads a duration column expressed in seconds
+func rowSeconds(row map[string]interface{}, name string) time.Duration {
+	return time.Duration(rowFloat(row, name) * float64(time.Second))
+}
+
+func rowTime(row map[string]interface{}, name string) (time.Time, error) {
+	s := rowString(row, name)
+	t, err := time.Parse(clickHouseDateTimeLayout, s)
+	if err != nil {
+		return t, fmt.Errorf("invalid %s %q: %w", name, s, err)
+	}
+	return t, nil
+}
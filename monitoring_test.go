@@ -0,0 +1,143 @@
+package tinybird
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func mockMonitoringQuery(mockClient *MockHttpClient, match func(q string) bool, rows []map[string]interface{}) {
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/sql",
+		mock.MatchedBy(func(params map[string]string) bool {
+			return match(params["q"])
+		}),
+		mock.AnythingOfType("*tinybird.EndpointResponse"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(3).(*EndpointResponse).Data = rows
+	})
+}
+
+func TestMonitoring_PipeStats(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockMonitoringQuery(mockClient, func(q string) bool {
+		return strings.Contains(q, "FROM tinybird.pipe_stats_rt") &&
+			strings.Contains(q, "start_datetime >= toDateTime('2024-05-01 00:00:00')") &&
+			strings.Contains(q, "pipe_name IN ('top_pages', 'o\\'brien')") &&
+			strings.Contains(q, "GROUP BY pipe_name") &&
+			strings.Contains(q, "LIMIT 1000")
+	}, []map[string]interface{}{
+		{
+			"pipe_name":    "top_pages",
+			"requests":     float64(200),
+			"errors":       float64(5),
+			"avg_duration": 0.02,
+			"p50_duration": 0.015,
+			"p95_duration": 0.25,
+			"p99_duration": 0.5,
+			"read_bytes":   "9007199254740993",
+			"read_rows":    float64(1000),
+		},
+	})
+
+	stats, err := client.Monitoring().PipeStats(context.Background(), &MonitoringOptions{
+		Since: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Names: []string{"top_pages", "o'brien"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(stats) != 1 {
+		t.Fatalf("got %d stats, want 1", len(stats))
+	}
+	s := stats[0]
+	if s.Requests != 200 || s.Errors != 5 || s.ErrorRate != 0.025 {
+		t.Errorf("Requests, Errors, ErrorRate = %d, %d, %v", s.Requests, s.Errors, s.ErrorRate)
+	}
+	if s.P95Duration != 250*time.Millisecond || s.P50Duration != 15*time.Millisecond {
+		t.Errorf("P50Duration, P95Duration = %v, %v", s.P50Duration, s.P95Duration)
+	}
+	if s.ReadBytes <= 0 {
+		t.Errorf("ReadBytes = %d, want the quoted 64-bit value", s.ReadBytes)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestMonitoring_IngestOperationsErrorsOnly(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockMonitoringQuery(mockClient, func(q string) bool {
+		return strings.Contains(q, "FROM tinybird.datasources_ops_log") &&
+			strings.Contains(q, "AND result = 'error'") &&
+			strings.Contains(q, "LIMIT 10")
+	}, []map[string]interface{}{
+		{
+			"timestamp":       "2024-05-01 12:30:00",
+			"event_type":      "append",
+			"datasource_id":   "t_123",
+			"datasource_name": "events",
+			"result":          "error",
+			"elapsed_time":    1.5,
+			"error":           "There was an error with file contents",
+			"rows":            float64(0),
+			"rows_quarantine": float64(12),
+			"job_id":          "job-1",
+			"import_id":       "",
+		},
+	})
+
+	operations, err := client.Monitoring().IngestOperations(context.Background(), &MonitoringOptions{
+		ErrorsOnly: true,
+		Limit:      10,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(operations) != 1 {
+		t.Fatalf("got %d operations, want 1", len(operations))
+	}
+	op := operations[0]
+	if op.Timestamp != time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC) {
+		t.Errorf("Timestamp = %v", op.Timestamp)
+	}
+	if op.Result != OperationResultError || op.ElapsedTime != 1500*time.Millisecond || op.RowsQuarantine != 12 {
+		t.Errorf("operation = %+v", op)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestMonitoring_BIQueriesIgnoresNames(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockMonitoringQuery(mockClient, func(q string) bool {
+		return strings.Contains(q, "FROM tinybird.bi_stats_rt") && !strings.Contains(q, " IN (")
+	}, []map[string]interface{}{
+		{
+			"start_datetime": "2024-05-01 12:30:00",
+			"query":          "SELECT 1",
+			"error_code":     float64(0),
+			"duration_ms":    float64(42),
+		},
+	})
+
+	queries, err := client.Monitoring().BIQueries(context.Background(), &MonitoringOptions{Names: []string{"ignored"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(queries) != 1 || queries[0].Duration != 42*time.Millisecond {
+		t.Errorf("queries = %+v", queries)
+	}
+}
@@ -0,0 +1,200 @@
+package tinybird
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strconv"
+)
+
+// DefaultPageSize is the number of rows requested per page when PaginationSpec.PageSize is not set.
+const DefaultPageSize = 100
+
+// PaginationStrategy selects how Paginate moves from one page to the next.
+type PaginationStrategy int
+
+const (
+	PaginateOffset PaginationStrategy = iota // Page number or row offset parameters
+	PaginateKeyset                           // A cursor taken from the last row of each page
+)
+
+// Paginator iterates over the pages of an endpoint. It is created by Paginate and
+// fetches nothing until iterated; each iteration starts again from the first page.
+type Paginator struct {
+	client      *ClientImpl
+	ctx         context.Context
+	endpoint    string
+	params      map[string]string
+	spec        PaginationSpec
+	callOptions []CallOption
+}
+
+// pageState identifies the page to request
+type pageState struct {
+	page    int    // Page number, for offset pagination by page
+	offset  int    // Rows already consumed, for offset pagination by row
+	cursor  string // Cursor value, for keyset pagination
+	fetched int    // Pages fetched so far
+}
+
+type pageResult struct {
+	response *EndpointResponse
+	err      error
+}
+
+func (c *ClientImpl) Paginate(ctx context.Context, endpointName string, params map[string]string, spec PaginationSpec, callOptions ...CallOption) *Paginator {
+	if spec.PageSize <= 0 {
+		spec.PageSize = DefaultPageSize
+	}
+	if spec.PageSizeParam == "" {
+		spec.PageSizeParam = "page_size"
+	}
+	if spec.PageParam == "" {
+		spec.PageParam = "page"
+	}
+	if spec.CursorParam == "" {
+		spec.CursorParam = "cursor"
+	}
+
+	return &Paginator{
+		client:      c,
+		ctx:         ctx,
+		endpoint:    endpointName,
+		params:      params,
+		spec:        spec,
+		callOptions: callOptions,
+	}
+}
+
+// Pages yields each page in order. Iteration stops after the last page or the
+// first error, which is yielded with a nil page.
+func (p *Paginator) Pages() iter.Seq2[*EndpointResponse, error] {
+	return func(yield func(*EndpointResponse, error) bool) {
+		if p.spec.Strategy == PaginateKeyset && p.spec.CursorColumn == "" {
+			yield(nil, fmt.Errorf("keyset pagination of %s requires a CursorColumn", p.endpoint))
+			return
+		}
+
+		// Stops an in-flight prefetch when the caller stops iterating
+		ctx, cancel := context.WithCancel(p.ctx)
+		defer cancel()
+
+		state := pageState{
+			page:   p.spec.FirstPage,
+			cursor: p.spec.InitialCursor,
+		}
+
+		pending := p.fetch(ctx, state)
+		for {
+			result := <-pending
+			if result.err != nil {
+				yield(nil, result.err)
+				return
+			}
+
+			next, more, err := p.next(state, result.response)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if more && p.spec.Prefetch {
+				pending = p.fetch(ctx, next)
+			}
+
+			if !yield(result.response, nil) || !more {
+				return
+			}
+
+			if !p.spec.Prefetch {
+				pending = p.fetch(ctx, next)
+			}
+			state = next
+		}
+	}
+}
+
+// Rows yields the rows of every page in order.
+func (p *Paginator) Rows() iter.Seq2[map[string]interface{}, error] {
+	return func(yield func(map[string]interface{}, error) bool) {
+		for page, err := range p.Pages() {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, row := range page.Data {
+				if !yield(row, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// fetch requests a page in the background
+func (p *Paginator) fetch(ctx context.Context, state pageState) <-chan pageResult {
+	params := make(map[string]string, len(p.params)+2)
+	for k, v := range p.params {
+		params[k] = v
+	}
+	params[p.spec.PageSizeParam] = strconv.Itoa(p.spec.PageSize)
+
+	switch {
+	case p.spec.Strategy == PaginateKeyset:
+		if state.cursor != "" {
+			params[p.spec.CursorParam] = state.cursor
+		}
+	case p.spec.OffsetParam != "":
+		params[p.spec.OffsetParam] = strconv.Itoa(state.offset)
+	default:
+		params[p.spec.PageParam] = strconv.Itoa(state.page)
+	}
+
+	results := make(chan pageResult, 1)
+	go func() {
+		response, err := p.client.CallEndpoint(ctx, p.endpoint, params, p.callOptions...)
+		results <- pageResult{response: response, err: err}
+	}()
+
+	return results
+}
+
+// next returns the state of the page following response and whether there is one
+func (p *Paginator) next(state pageState, response *EndpointResponse) (pageState, bool, error) {
+	rows := len(response.Data)
+
+	next := state
+	next.page++
+	next.offset += rows
+	next.fetched++
+
+	if p.spec.Strategy == PaginateKeyset && rows > 0 {
+		// Data holds float64 numbers, which would round 64-bit integer cursors
+		data, err := response.exactData()
+		if err != nil {
+			return next, false, err
+		}
+		value, ok := data[rows-1][p.spec.CursorColumn]
+		if !ok || value == nil {
+			return next, false, fmt.Errorf("cursor column %s missing from the last row of %s", p.spec.CursorColumn, p.endpoint)
+		}
+		next.cursor = stringify(value)
+	}
+
+	switch {
+	case rows == 0 || rows < p.spec.PageSize:
+		// A short page is the last one
+		return next, false, nil
+	case p.spec.MaxPages > 0 && next.fetched >= p.spec.MaxPages:
+		return next, false, nil
+	case response.RowsBeforeLimit > 0:
+		// rows_before_limit_at_least counts the rows the query matched before LIMIT:
+		// every row including skipped ones for offsets, or the remaining rows for cursors
+		if p.spec.Strategy == PaginateKeyset {
+			return next, response.RowsBeforeLimit > rows, nil
+		}
+		return next, response.RowsBeforeLimit > next.offset, nil
+	}
+
+	return next, true, nil
+}
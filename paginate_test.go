@@ -0,0 +1,176 @@
+package tinybird
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// mockPages serves total rows of an endpoint, paged by the page or cursor parameter
+func mockPages(mockClient *MockHttpClient, total int, keyset bool) {
+	mockClient.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		params := args.Get(2).(map[string]string)
+		pageSize, _ := strconv.Atoi(params["page_size"])
+
+		start := 0
+		if keyset {
+			if cursor, ok := params["cursor"]; ok {
+				last, _ := strconv.Atoi(cursor)
+				start = last + 1
+			}
+		} else {
+			page, _ := strconv.Atoi(params["page"])
+			start = page * pageSize
+		}
+
+		response := args.Get(3).(*EndpointResponse)
+		for id := start; id < min(start+pageSize, total); id++ {
+			response.Data = append(response.Data, map[string]interface{}{"id": float64(id)})
+		}
+		response.Rows = len(response.Data)
+		response.RowsBeforeLimit = total
+		if keyset {
+			response.RowsBeforeLimit = total - start
+		}
+	})
+}
+
+func TestPaginate_OffsetPages(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+	mockPages(mockClient, 25, false)
+
+	var sizes []int
+	for page, err := range client.Paginate(context.Background(), "events", nil, PaginationSpec{PageSize: 10}).Pages() {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		sizes = append(sizes, len(page.Data))
+	}
+
+	if fmt.Sprint(sizes) != "[10 10 5]" {
+		t.Errorf("page sizes = %v, want [10 10 5]", sizes)
+	}
+	mockClient.AssertNumberOfCalls(t, "Get", 3)
+}
+
+func TestPaginate_StopsAtRowsBeforeLimit(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+	mockPages(mockClient, 20, false)
+
+	rows := 0
+	for _, err := range client.Paginate(context.Background(), "events", nil, PaginationSpec{PageSize: 10}).Rows() {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rows++
+	}
+
+	if rows != 20 {
+		t.Errorf("rows = %d, want 20", rows)
+	}
+	// A full last page does not need an empty page to confirm the end
+	mockClient.AssertNumberOfCalls(t, "Get", 2)
+}
+
+func TestPaginate_KeysetWithPrefetch(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+	mockPages(mockClient, 23, true)
+
+	var ids []float64
+	paginator := client.Paginate(context.Background(), "events", nil, PaginationSpec{
+		Strategy:     PaginateKeyset,
+		PageSize:     5,
+		CursorColumn: "id",
+		Prefetch:     true,
+	})
+	for row, err := range paginator.Rows() {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, row["id"].(float64))
+	}
+
+	if len(ids) != 23 {
+		t.Fatalf("rows = %d, want 23", len(ids))
+	}
+	for i, id := range ids {
+		if id != float64(i) {
+			t.Fatalf("row %d has id %v", i, id)
+		}
+	}
+}
+
+func TestPaginate_KeysetLargeIntegerCursor(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	var cursors []string
+	mockClient.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		params := args.Get(2).(map[string]string)
+		cursors = append(cursors, params["cursor"])
+
+		body := `{"data":[{"id":1234567890123456788},{"id":1234567890123456789}],"rows":2}`
+		if params["cursor"] != "" {
+			body = `{"data":[],"rows":0}`
+		}
+		if err := json.Unmarshal([]byte(body), args.Get(3)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	paginator := client.Paginate(context.Background(), "events", nil, PaginationSpec{
+		Strategy:     PaginateKeyset,
+		PageSize:     2,
+		CursorColumn: "id",
+	})
+	for _, err := range paginator.Rows() {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if len(cursors) != 2 || cursors[1] != "1234567890123456789" {
+		t.Errorf("cursors = %q, want the exact id of the last row", cursors)
+	}
+}
+
+func TestPaginate_BreakStopsFetching(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+	mockPages(mockClient, 100, false)
+
+	for range client.Paginate(context.Background(), "events", nil, PaginationSpec{PageSize: 10}).Pages() {
+		break
+	}
+
+	mockClient.AssertNumberOfCalls(t, "Get", 1)
+}
+
+func TestPaginate_YieldsErrors(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+	mockClient.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("boom"))
+
+	var got error
+	for _, err := range client.Paginate(context.Background(), "events", nil, PaginationSpec{}).Rows() {
+		got = err
+	}
+	if got == nil {
+		t.Fatal("expected error")
+	}
+
+	for _, err := range client.Paginate(context.Background(), "events", nil, PaginationSpec{Strategy: PaginateKeyset}).Pages() {
+		got = err
+	}
+	if got == nil || got.Error() != "keyset pagination of events requires a CursorColumn" {
+		t.Errorf("error = %v, want missing CursorColumn", got)
+	}
+}
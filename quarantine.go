@@ -0,0 +1,252 @@
+package tinybird
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultQuarantineLimit is the number of quarantine records fetched when no limit is given.
+const DefaultQuarantineLimit = 1000
+
+// quarantineDeleteBatchSize bounds the number of records matched by a single delete condition
+const quarantineDeleteBatchSize = 100
+
+const clickHouseDateTimeLayout = "2006-01-02 15:04:05"
+
+// Columns Tinybird adds to every quarantine datasource
+const (
+	quarantineErrorColumnField = "c__error_column"
+	quarantineErrorField       = "c__error"
+	quarantineImportIDField    = "c__import_id"
+	quarantineInsertionField   = "insertion_date"
+)
+
+func (c *ClientImpl) QueryQuarantine(ctx context.Context, datasourceName string, options *QuarantineQueryOptions) ([]QuarantineRecord, error) {
+	if options == nil {
+		options = &QuarantineQueryOptions{}
+	}
+
+	limit := options.Limit
+	if limit <= 0 {
+		limit = DefaultQuarantineLimit
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "SELECT * FROM %s WHERE 1", quoteIdentifier(datasourceName+"_quarantine"))
+	sb.WriteString(timeRange(quarantineInsertionField, options.Since, options.Until))
+	if options.Where != "" {
+		fmt.Fprintf(&sb, " AND (%s)", options.Where)
+	}
+	fmt.Fprintf(&sb, " ORDER BY %s LIMIT %d", quarantineInsertionField, limit)
+
+	response, err := c.Query(ctx, sb.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]QuarantineRecord, 0, len(response.Data))
+	for _, row := range response.Data {
+		record, err := parseQuarantineRecord(row)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func (c *ClientImpl) ReplayQuarantine(ctx context.Context, datasourceName string, fix ReplayFunc, options *ReplayQuarantineOptions) (*ReplayQuarantineResult, error) {
+	if options == nil {
+		options = &ReplayQuarantineOptions{}
+	}
+
+	records, err := c.QueryQuarantine(ctx, datasourceName, options.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReplayQuarantineResult{}
+
+	var payload bytes.Buffer
+	var replayed []QuarantineRecord
+
+	for _, record := range records {
+		row, replay, err := fix(record)
+		if err != nil {
+			return result, fmt.Errorf("replay callback failed for record inserted at %s: %w", record.InsertionDate.Format(clickHouseDateTimeLayout), err)
+		}
+		if !replay {
+			result.Skipped++
+			continue
+		}
+
+		line, err := json.Marshal(row)
+		if err != nil {
+			return result, fmt.Errorf("failed to marshal replayed row: %w", err)
+		}
+		payload.Write(line)
+		payload.WriteByte('\n')
+
+		replayed = append(replayed, record)
+	}
+
+	if len(replayed) == 0 {
+		return result, nil
+	}
+
+	sendOptions := &SendEventsOptions{}
+	if options.SendOptions != nil {
+		copied := *options.SendOptions
+		sendOptions = &copied
+	}
+	// Replayed rows are always NDJSON, one fixed row per line
+	sendOptions.Format = ""
+
+	write, err := c.SendEvents(ctx, datasourceName, payload.Bytes(), sendOptions)
+	result.Write = write
+	if err != nil {
+		return result, err
+	}
+	result.Replayed = len(replayed)
+
+	if !options.DeleteReplayed {
+		return result, nil
+	}
+
+	for start := 0; start < len(replayed); start += quarantineDeleteBatchSize {
+		end := min(start+quarantineDeleteBatchSize, len(replayed))
+
+		job, err := c.DeleteData(ctx, datasourceName+"_quarantine", quarantineDeleteCondition(replayed[start:end]))
+		if err != nil {
+			return result, fmt.Errorf("failed to delete replayed quarantine rows: %w", err)
+		}
+
+		if options.WaitForDelete {
+			job, err = c.WaitForJob(ctx, job.ID, 0)
+			if err != nil {
+				return result, fmt.Errorf("failed to delete replayed quarantine rows: %w", err)
+			}
+		}
+
+		result.DeleteJobs = append(result.DeleteJobs, job)
+	}
+
+	return result, nil
+}
+
+func (c *ClientImpl) DeleteData(ctx context.Context, datasourceName string, condition string) (*Job, error) {
+	form := url.Values{}
+	form.Set("delete_condition", condition)
+
+	var job Job
+
+	reqUrl := c.apiURL("datasources/" + url.PathEscape(datasourceName) + "/delete")
+	err := c.httpClient.PostRaw(ctx, reqUrl, []byte(form.Encode()), "application/x-www-form-urlencoded", "", &job)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.ID == "" {
+		job.ID = job.JobID
+	}
+
+	return &job, nil
+}
+
+// parseQuarantineRecord splits a quarantine row into Tinybird's bookkeeping columns and the original fields
+func parseQuarantineRecord(row map[string]interface{}) (QuarantineRecord, error) {
+	record := QuarantineRecord{
+		Fields: make(map[string]interface{}, len(row)),
+	}
+
+	for name, value := range row {
+		switch name {
+		case quarantineErrorColumnField:
+			record.ErrorColumns = toStrings(value)
+		case quarantineErrorField:
+			record.Errors = toStrings(value)
+		case quarantineImportIDField:
+			if s, ok := value.(string); ok {
+				record.ImportID = s
+			}
+		case quarantineInsertionField:
+			s, _ := value.(string)
+			insertionDate, err := time.Parse(clickHouseDateTimeLayout, s)
+			if err != nil {
+				return record, fmt.Errorf("invalid quarantine insertion_date %q: %w", s, err)
+			}
+			record.InsertionDate = insertionDate
+		default:
+			record.Fields[name] = value
+		}
+	}
+
+	return record, nil
+}
+
+// quarantineDeleteCondition builds a condition matching exactly the given quarantine records
+func quarantineDeleteCondition(records []QuarantineRecord) string {
+	conditions := make([]string, 0, len(records))
+
+	for _, record := range records {
+		parts := []string{
+			fmt.Sprintf("%s = toDateTime(%s)", quarantineInsertionField, quoteString(record.InsertionDate.Format(clickHouseDateTimeLayout))),
+		}
+
+		// Sort field names so the condition is deterministic
+		names := make([]string, 0, len(record.Fields))
+		for name := range record.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			value := record.Fields[name]
+			if value == nil {
+				parts = append(parts, fmt.Sprintf("isNull(%s)", quoteIdentifier(name)))
+				continue
+			}
+			parts = append(parts, fmt.Sprintf("%s = %s", quoteIdentifier(name), quoteString(stringify(value))))
+		}
+
+		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return strings.Join(conditions, " OR ")
+}
+
+func toStrings(value interface{}) []string {
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		result = append(result, stringify(v))
+	}
+	return result
+}
+
+// stringify renders a decoded JSON value the way ClickHouse stores it in a String column
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+}
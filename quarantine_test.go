@@ -0,0 +1,166 @@
+package tinybird
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func quarantineRows() []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"c__error_column": []interface{}{"amount"},
+			"c__error":        []interface{}{"value 'abc' cannot be parsed as Float64"},
+			"c__import_id":    nil,
+			"insertion_date":  "2024-05-01 12:30:00",
+			"amount":          "abc",
+			"user":            "alice",
+		},
+		{
+			"c__error_column": []interface{}{"amount"},
+			"c__error":        []interface{}{"value '' cannot be parsed as Float64"},
+			"insertion_date":  "2024-05-01 12:31:00",
+			"amount":          nil,
+			"user":            "bob",
+		},
+	}
+}
+
+func TestQueryQuarantine_ParsesRecords(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/sql",
+		mock.MatchedBy(func(params map[string]string) bool {
+			q := params["q"]
+			return strings.Contains(q, "FROM `payments_quarantine`") &&
+				strings.Contains(q, "insertion_date >= toDateTime('2024-05-01 00:00:00')") &&
+				strings.Contains(q, "LIMIT 10") &&
+				strings.HasSuffix(q, "FORMAT JSON")
+		}),
+		mock.AnythingOfType("*tinybird.EndpointResponse"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(3).(*EndpointResponse).Data = quarantineRows()
+	})
+
+	records, err := client.QueryQuarantine(context.Background(), "payments", &QuarantineQueryOptions{
+		Since: since,
+		Limit: 10,
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+
+	first := records[0]
+	if first.InsertionDate != time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC) {
+		t.Errorf("InsertionDate = %v", first.InsertionDate)
+	}
+	if len(first.ErrorColumns) != 1 || first.ErrorColumns[0] != "amount" {
+		t.Errorf("ErrorColumns = %v, want [amount]", first.ErrorColumns)
+	}
+	if first.Fields["user"] != "alice" || len(first.Fields) != 2 {
+		t.Errorf("Fields = %v, want amount and user only", first.Fields)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestReplayQuarantine_ResendsAndDeletes(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/sql",
+		mock.Anything,
+		mock.AnythingOfType("*tinybird.EndpointResponse"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(3).(*EndpointResponse).Data = quarantineRows()
+	})
+
+	mockClient.On("PostRaw",
+		mock.Anything,
+		"https://api.tinybird.co/v0/events?name=payments",
+		[]byte("{\"amount\":0,\"user\":\"alice\"}\n"),
+		"application/x-ndjson",
+		"",
+		mock.AnythingOfType("*tinybird.WriteResponse"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(5).(*WriteResponse).SuccessfulRows = 1
+	})
+
+	var deleteCondition string
+	mockClient.On("PostRaw",
+		mock.Anything,
+		"https://api.tinybird.co/v0/datasources/payments_quarantine/delete",
+		mock.MatchedBy(func(body []byte) bool {
+			form, err := url.ParseQuery(string(body))
+			deleteCondition = form.Get("delete_condition")
+			return err == nil
+		}),
+		"application/x-www-form-urlencoded",
+		"",
+		mock.AnythingOfType("*tinybird.Job"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(5).(*Job).JobID = "job-1"
+	})
+
+	result, err := client.ReplayQuarantine(context.Background(), "payments", func(record QuarantineRecord) (map[string]interface{}, bool, error) {
+		if record.Fields["user"] == "bob" {
+			return nil, false, nil
+		}
+		return map[string]interface{}{"user": record.Fields["user"], "amount": 0}, true, nil
+	}, &ReplayQuarantineOptions{DeleteReplayed: true})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Replayed != 1 || result.Skipped != 1 {
+		t.Errorf("Replayed = %d, Skipped = %d, want 1 and 1", result.Replayed, result.Skipped)
+	}
+	if result.Write.SuccessfulRows != 1 {
+		t.Errorf("SuccessfulRows = %d, want 1", result.Write.SuccessfulRows)
+	}
+	if len(result.DeleteJobs) != 1 || result.DeleteJobs[0].ID != "job-1" {
+		t.Errorf("DeleteJobs = %+v, want job-1", result.DeleteJobs)
+	}
+
+	expectedCondition := "(insertion_date = toDateTime('2024-05-01 12:30:00') AND `amount` = 'abc' AND `user` = 'alice')"
+	if deleteCondition != expectedCondition {
+		t.Errorf("delete condition = %q, want %q", deleteCondition, expectedCondition)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestWithJSONFormat(t *testing.T) {
+	tests := []struct {
+		sql      string
+		expected string
+	}{
+		{sql: "SELECT 1", expected: "SELECT 1 FORMAT JSON"},
+		{sql: "SELECT 1;", expected: "SELECT 1 FORMAT JSON"},
+		{sql: "SELECT 1 FORMAT CSV", expected: "SELECT 1 FORMAT CSV"},
+		{sql: "select 1 format JSONEachRow", expected: "select 1 format JSONEachRow"},
+	}
+
+	for _, tt := range tests {
+		if got := withJSONFormat(tt.sql); got != tt.expected {
+			t.Errorf("withJSONFormat(%q) = %q, want %q", tt.sql, got, tt.expected)
+		}
+	}
+}
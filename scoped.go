@@ -0,0 +1,91 @@
+package tinybird
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/NOLLYWOOD-COM/tinybird/internal/httpclient"
+)
+
+func (c *ClientImpl) WithCredentials(token string, host string) Client {
+	options := *c.options
+	if token != "" {
+		options.Token = token
+	}
+	if host != "" {
+		options.Host = host
+	}
+
+	// The derived client has no spool: spooled payloads are replayed by the
+	// parent with its own token. Cache keys include the host and token, so the
+	// cache can be shared safely.
+	options.SpoolDir = ""
+
+	return &ClientImpl{
+		httpClient: &scopedHTTPClient{inner: c.httpClient, token: options.Token},
+		options:    &options,
+		cache:      c.cache,
+		calls:      c.calls,
+		hedger:     c.hedger,
+	}
+}
+
+func (c *ClientImpl) ForBranch(branch *Branch) (Client, error) {
+	if branch.Token == "" {
+		return nil, fmt.Errorf("branch %s has no token", branch.Name)
+	}
+	return c.WithCredentials(branch.Token, branch.Host), nil
+}
+
+// scopedHTTPClient authenticates every request with its own token, unless a
+// call sets one with WithToken
+type scopedHTTPClient struct {
+	inner httpclient.Client
+	token string
+}
+
+func (s *scopedHTTPClient) scope(ctx context.Context) context.Context {
+	overrides := httpclient.RequestOptionsFrom(ctx)
+	if overrides.Token != "" {
+		return ctx
+	}
+	overrides.Token = s.token
+	return httpclient.WithRequestOptions(ctx, overrides)
+}
+
+func (s *scopedHTTPClient) Delete(ctx context.Context, url string, params map[string]string, result interface{}) error {
+	return s.inner.Delete(s.scope(ctx), url, params, result)
+}
+
+func (s *scopedHTTPClient) Get(ctx context.Context, url string, params map[string]string, result interface{}) error {
+	return s.inner.Get(s.scope(ctx), url, params, result)
+}
+
+func (s *scopedHTTPClient) Patch(ctx context.Context, url string, body interface{}, result interface{}) error {
+	return s.inner.Patch(s.scope(ctx), url, body, result)
+}
+
+func (s *scopedHTTPClient) Post(ctx context.Context, url string, body interface{}, result interface{}) error {
+	return s.inner.Post(s.scope(ctx), url, body, result)
+}
+
+func (s *scopedHTTPClient) PostMultipart(ctx context.Context, url string, fieldName string, fileName string, fileData []byte, result interface{}) error {
+	return s.inner.PostMultipart(s.scope(ctx), url, fieldName, fileName, fileData, result)
+}
+
+func (s *scopedHTTPClient) PostRaw(ctx context.Context, url string, body []byte, contentType string, contentEncoding string, result interface{}) error {
+	return s.inner.PostRaw(s.scope(ctx), url, body, contentType, contentEncoding, result)
+}
+
+func (s *scopedHTTPClient) PostStream(ctx context.Context, url string, getBody func() (io.ReadCloser, error), contentType string, contentEncoding string, result interface{}) error {
+	return s.inner.PostStream(s.scope(ctx), url, getBody, contentType, contentEncoding, result)
+}
+
+func (s *scopedHTTPClient) Put(ctx context.Context, url string, body interface{}, result interface{}) error {
+	return s.inner.Put(s.scope(ctx), url, body, result)
+}
+
+func (s *scopedHTTPClient) PutRaw(ctx context.Context, url string, body []byte, contentType string, contentEncoding string, result interface{}) error {
+	return s.inner.PutRaw(s.scope(ctx), url, body, contentType, contentEncoding, result)
+}
@@ -0,0 +1,102 @@
+package tinybird
+
+import (
+	"context"
+	"net/url"
+)
+
+// sinkJobKind is the job kind of sink pipe runs
+const sinkJobKind = "sink"
+
+// SinksClient manages sink pipes, which export query results to object storage or Kafka.
+type SinksClient interface {
+	// Create turns a pipe node into a sink that exports through an existing connection.
+	Create(ctx context.Context, pipeName string, nodeName string, spec SinkSpec) (*SinkPipe, error)
+	// Run triggers an on-demand export.
+	//
+	// options: Optional parameters and whether to wait for the export to finish.
+	Run(ctx context.Context, pipeName string, options *SinkRunOptions) (*SinkRun, error)
+	// GetRun returns an export with the files it wrote.
+	GetRun(ctx context.Context, jobID string) (*SinkRun, error)
+	// ListRuns returns the most recent exports of a sink pipe, newest first.
+	//
+	// limit: Maximum number of runs, 0 returns every run the API reports.
+	ListRuns(ctx context.Context, pipeName string, limit int) ([]SinkRun, error)
+}
+
+type sinksClient struct {
+	client *ClientImpl
+}
+
+func (c *ClientImpl) Sinks() SinksClient {
+	return &sinksClient{client: c}
+}
+
+func (s *sinksClient) Create(ctx context.Context, pipeName string, nodeName string, spec SinkSpec) (*SinkPipe, error) {
+	params := map[string]string{"connection": spec.Connection}
+	for name, value := range map[string]string{
+		"path":           spec.Path,
+		"file_template":  spec.FileTemplate,
+		"format":         spec.Format,
+		"compression":    spec.Compression,
+		"write_strategy": spec.WriteStrategy,
+		"kafka_topic":    spec.KafkaTopic,
+		"schedule_cron":  spec.ScheduleCron,
+	} {
+		if value != "" {
+			params[name] = value
+		}
+	}
+
+	var pipe SinkPipe
+
+	reqUrl := withQueryParams(s.client.apiURL("pipes/"+url.PathEscape(pipeName)+"/nodes/"+url.PathEscape(nodeName)+"/sink"), params)
+	if err := s.client.httpClient.PostRaw(ctx, reqUrl, nil, "", "", &pipe); err != nil {
+		return nil, err
+	}
+
+	return &pipe, nil
+}
+
+func (s *sinksClient) Run(ctx context.Context, pipeName string, options *SinkRunOptions) (*SinkRun, error) {
+	if options == nil {
+		options = &SinkRunOptions{}
+	}
+
+	var response jobResponse
+
+	reqUrl := withQueryParams(s.client.apiURL("pipes/"+url.PathEscape(pipeName)+"/sink"), options.Params)
+	if err := s.client.httpClient.PostRaw(ctx, reqUrl, nil, "", "", &response); err != nil {
+		return nil, err
+	}
+
+	job := response.job()
+	if !options.Wait {
+		return &SinkRun{Job: *job}, nil
+	}
+
+	if _, err := s.client.WaitForJob(ctx, job.ID, options.PollInterval); err != nil {
+		return nil, err
+	}
+
+	return s.GetRun(ctx, job.ID)
+}
+
+func (s *sinksClient) GetRun(ctx context.Context, jobID string) (*SinkRun, error) {
+	var run SinkRun
+
+	err := s.client.httpClient.Get(ctx, s.client.apiURL("jobs/"+url.PathEscape(jobID)), nil, &run)
+	if err != nil {
+		return nil, err
+	}
+
+	if run.ID == "" {
+		run.ID = run.JobID
+	}
+
+	return &run, nil
+}
+
+func (s *sinksClient) ListRuns(ctx context.Context, pipeName string, limit int) ([]SinkRun, error) {
+	return listJobs[SinkRun](ctx, s.client, sinkJobKind, pipeName, limit)
+}
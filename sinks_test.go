@@ -0,0 +1,116 @@
+package tinybird
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSinks_Create(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockClient.On("PostRaw",
+		mock.Anything,
+		"https://api.tinybird.co/v0/pipes/export_events/nodes/daily/sink?connection=s3_exports&format=parquet&path=s3%3A%2F%2Fexports%2Fevents&schedule_cron=0+2+%2A+%2A+%2A",
+		[]byte(nil),
+		"",
+		"",
+		mock.AnythingOfType("*tinybird.SinkPipe"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(5).(*SinkPipe) = SinkPipe{Name: "export_events", Type: "sink"}
+	})
+
+	pipe, err := client.Sinks().Create(context.Background(), "export_events", "daily", SinkSpec{
+		Connection:   "s3_exports",
+		Path:         "s3://exports/events",
+		Format:       "parquet",
+		ScheduleCron: "0 2 * * *",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pipe.Type != "sink" {
+		t.Errorf("Type = %q, want sink", pipe.Type)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestSinks_RunAndWait(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockClient.On("PostRaw",
+		mock.Anything,
+		"https://api.tinybird.co/v0/pipes/export_events/sink?day=2024-05-01",
+		[]byte(nil),
+		"",
+		"",
+		mock.AnythingOfType("*tinybird.jobResponse"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(5).(*jobResponse).Job = Job{JobID: "job-1", Kind: sinkJobKind, Status: JobStatusWaiting}
+	})
+
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/jobs/job-1",
+		mock.Anything,
+		mock.AnythingOfType("*tinybird.Job"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(3).(*Job) = Job{ID: "job-1", Status: JobStatusDone}
+	})
+
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/jobs/job-1",
+		mock.Anything,
+		mock.AnythingOfType("*tinybird.SinkRun"),
+	).Return(nil).Run(func(args mock.Arguments) {
+		run := args.Get(3).(*SinkRun)
+		run.ID = "job-1"
+		run.Status = JobStatusDone
+		run.Files = []SinkFile{{Path: "s3://exports/events/2024-05-01.parquet", Rows: 42}}
+		run.Statistics = SinkStatistics{Rows: 42, Files: 1}
+	})
+
+	run, err := client.Sinks().Run(context.Background(), "export_events", &SinkRunOptions{
+		Params: map[string]string{"day": "2024-05-01"},
+		Wait:   true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if run.Status != JobStatusDone || len(run.Files) != 1 || run.Statistics.Rows != 42 {
+		t.Errorf("run = %+v, want a finished export with its files", run)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestSinks_ListRuns(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/jobs",
+		map[string]string{"kind": sinkJobKind, "pipe_name": "export_events"},
+		mock.Anything,
+	).Return(nil).Run(func(args mock.Arguments) {
+		response := args.Get(3).(*struct {
+			Jobs []SinkRun `json:"jobs"`
+		})
+		response.Jobs = []SinkRun{{Job: Job{JobID: "job-2"}}, {Job: Job{JobID: "job-1"}}}
+	})
+
+	runs, err := client.Sinks().ListRuns(context.Background(), "export_events", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runs) != 2 || runs[0].ID != "job-2" {
+		t.Errorf("runs = %+v", runs)
+	}
+}
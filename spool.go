@@ -0,0 +1,214 @@
+package tinybird
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/NOLLYWOOD-COM/tinybird/internal/spool"
+)
+
+// DefaultSpoolRetryInterval is how long the spool waits before retrying delivery after a transient failure.
+const DefaultSpoolRetryInterval = 10 * time.Second
+
+// ErrSpooled is wrapped by SendEvents errors when a payload that failed with a
+// transient error was persisted to the spool and will be delivered in the background.
+var ErrSpooled = errors.New("payload spooled for background delivery")
+
+// spooledPayload is the envelope persisted for each spooled request body
+type spooledPayload struct {
+	Datasource          string `json:"datasource"`
+	Body                []byte `json:"body"`
+	Wait                bool   `json:"wait,omitempty"`
+	Format              string `json:"format,omitempty"`
+	Compress            bool   `json:"compress,omitempty"`
+	CompressionEncoding string `json:"compression_encoding,omitempty"`
+}
+
+// eventSpool persists failed Events API payloads and replays them in order from a background goroutine
+type eventSpool struct {
+	client        *ClientImpl
+	queue         *spool.Spool
+	retryInterval time.Duration
+	onError       func(error)
+
+	notify chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	replayed atomic.Uint64
+	dropped  atomic.Uint64
+}
+
+func newEventSpool(client *ClientImpl, options *ClientOptions) (*eventSpool, error) {
+	queue, err := spool.Open(options.SpoolDir, spool.Options{MaxBytes: options.SpoolMaxBytes})
+	if err != nil {
+		return nil, err
+	}
+
+	retryInterval := options.SpoolRetryInterval
+	if retryInterval <= 0 {
+		retryInterval = DefaultSpoolRetryInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &eventSpool{
+		client:        client,
+		queue:         queue,
+		retryInterval: retryInterval,
+		onError:       options.SpoolErrorHandler,
+		notify:        make(chan struct{}, 1),
+		cancel:        cancel,
+		done:          make(chan struct{}),
+	}
+
+	// Payloads recovered from a previous run are replayed straight away
+	go s.run(ctx)
+
+	return s, nil
+}
+
+// enqueue persists a payload that failed with cause and returns the error to report to the caller
+func (s *eventSpool) enqueue(datasourceName string, body []byte, options *SendEventsOptions, cause error) error {
+	record, err := json.Marshal(spooledPayload{
+		Datasource:          datasourceName,
+		Body:                body,
+		Wait:                options.Wait,
+		Format:              options.Format,
+		Compress:            options.Compress,
+		CompressionEncoding: options.CompressionEncoding,
+	})
+	if err == nil {
+		err = s.queue.Append(record)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("failed to spool payload for %s: %w", datasourceName, err)
+		s.reportError(err)
+		return fmt.Errorf("%w (%v)", cause, err)
+	}
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+
+	return fmt.Errorf("%w: %w", ErrSpooled, cause)
+}
+
+func (s *eventSpool) run(ctx context.Context) {
+	defer close(s.done)
+
+	for {
+		delivered, retry, err := s.replayNext(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			s.reportError(err)
+		}
+		if delivered {
+			continue
+		}
+
+		// An empty spool wakes up as soon as a payload is spooled, while a
+		// failing API is given the full retry interval to recover
+		wake := s.notify
+		if retry {
+			wake = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-time.After(s.retryInterval):
+		}
+	}
+}
+
+// replayNext attempts to deliver the oldest spooled payload. It reports whether
+// the payload was removed from the spool, either delivered or dropped, and
+// whether delivery should be retried later.
+func (s *eventSpool) replayNext(ctx context.Context) (bool, bool, error) {
+	record, ok, err := s.queue.Peek()
+	if err != nil {
+		return false, true, err
+	}
+	if !ok {
+		return false, false, nil
+	}
+
+	var payload spooledPayload
+	if err := json.Unmarshal(record, &payload); err != nil {
+		s.dropped.Add(1)
+		return true, false, errors.Join(fmt.Errorf("dropped unreadable spooled payload: %w", err), s.queue.Ack())
+	}
+
+	_, err = s.client.postEvents(ctx, payload.Datasource, payload.Body, &SendEventsOptions{
+		Wait:                payload.Wait,
+		Format:              payload.Format,
+		Compress:            payload.Compress,
+		CompressionEncoding: payload.CompressionEncoding,
+	})
+
+	switch {
+	case err == nil:
+		s.replayed.Add(1)
+		return true, false, s.queue.Ack()
+	case IsRetryable(err) || ctx.Err() != nil:
+		return false, true, nil
+	default:
+		// The API rejected the payload, so retrying would never succeed
+		s.dropped.Add(1)
+		return true, false, errors.Join(fmt.Errorf("dropped spooled payload for %s: %w", payload.Datasource, err), s.queue.Ack())
+	}
+}
+
+func (s *eventSpool) stats() SpoolStats {
+	backlog := s.queue.Stats()
+
+	return SpoolStats{
+		Enabled:        true,
+		PendingRecords: backlog.Records,
+		PendingBytes:   backlog.Bytes,
+		Segments:       backlog.Segments,
+		Replayed:       s.replayed.Load(),
+		Dropped:        s.dropped.Load(),
+		Corrupt:        backlog.Corrupt,
+	}
+}
+
+func (s *eventSpool) close() error {
+	s.cancel()
+	<-s.done
+	return s.queue.Close()
+}
+
+func (s *eventSpool) reportError(err error) {
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+func (c *ClientImpl) SpoolStats() SpoolStats {
+	if c.spool == nil {
+		return SpoolStats{}
+	}
+	return c.spool.stats()
+}
+
+func (c *ClientImpl) Close() error {
+	if c.drift != nil {
+		c.drift.close()
+	}
+
+	if c.spool == nil {
+		return nil
+	}
+	return c.spool.close()
+}
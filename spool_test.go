@@ -0,0 +1,142 @@
+package tinybird
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func newSpoolTestClient(t *testing.T, mockClient *MockHttpClient, dir string) *ClientImpl {
+	t.Helper()
+
+	options := &ClientOptions{
+		Protocol:           "https",
+		Host:               "api.tinybird.co",
+		ApiVersion:         "v0",
+		Token:              "test-token",
+		SpoolDir:           dir,
+		SpoolRetryInterval: 10 * time.Millisecond,
+		SpoolErrorHandler: func(err error) {
+			t.Errorf("unexpected spool error: %v", err)
+		},
+	}
+
+	client := NewClient(options, mockClient).(*ClientImpl)
+	if client.spool == nil {
+		t.Fatal("expected spool to be enabled")
+	}
+	return client
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSendEvents_SpoolsAndReplaysTransientFailures(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newSpoolTestClient(t, mockClient, t.TempDir())
+	defer client.Close()
+
+	data := []byte(`{"event":"outage"}`)
+	outage := fmt.Errorf("%w: HTTP 503: Service Unavailable", ErrMaxRetriesExceeded)
+
+	mockClient.On("PostRaw",
+		mock.Anything,
+		"https://api.tinybird.co/v0/events?name=events&wait=true",
+		data,
+		"application/x-ndjson",
+		"",
+		mock.AnythingOfType("*tinybird.WriteResponse"),
+	).Return(outage).Twice()
+
+	mockClient.On("PostRaw",
+		mock.Anything,
+		"https://api.tinybird.co/v0/events?name=events&wait=true",
+		data,
+		"application/x-ndjson",
+		"",
+		mock.AnythingOfType("*tinybird.WriteResponse"),
+	).Return(nil)
+
+	_, err := client.SendEvents(context.Background(), "events", data, &SendEventsOptions{Wait: true})
+
+	if !errors.Is(err, ErrSpooled) {
+		t.Fatalf("error = %v, want ErrSpooled", err)
+	}
+	if !errors.Is(err, ErrMaxRetriesExceeded) {
+		t.Errorf("error = %v, want it to wrap the original failure", err)
+	}
+
+	waitFor(t, func() bool { return client.SpoolStats().Replayed == 1 })
+
+	stats := client.SpoolStats()
+	if stats.PendingRecords != 0 || stats.Dropped != 0 {
+		t.Errorf("SpoolStats() = %+v, want an empty backlog", stats)
+	}
+
+	mockClient.AssertNumberOfCalls(t, "PostRaw", 3)
+}
+
+func TestSendEvents_DoesNotSpoolRejectedPayloads(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newSpoolTestClient(t, mockClient, t.TempDir())
+	defer client.Close()
+
+	rejected := &HTTPError{StatusCode: 400, Status: "400 Bad Request"}
+
+	mockClient.On("PostRaw",
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+	).Return(rejected)
+
+	_, err := client.SendEvents(context.Background(), "events", []byte(`{}`), nil)
+
+	if err != rejected {
+		t.Fatalf("error = %v, want %v", err, rejected)
+	}
+
+	if stats := client.SpoolStats(); stats.PendingRecords != 0 {
+		t.Errorf("PendingRecords = %d, want 0", stats.PendingRecords)
+	}
+}
+
+func TestSpool_RecoversBacklogOnRestart(t *testing.T) {
+	dir := t.TempDir()
+	outage := fmt.Errorf("%w: request failed", ErrMaxRetriesExceeded)
+
+	// First client spools a payload while the API is down, then shuts down
+	downClient := NewMockHttpClient()
+	downClient.On("PostRaw", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(outage)
+
+	client := newSpoolTestClient(t, downClient, dir)
+	if _, err := client.SendEvents(context.Background(), "events", []byte(`{"n":1}`), nil); !errors.Is(err, ErrSpooled) {
+		t.Fatalf("error = %v, want ErrSpooled", err)
+	}
+	client.Close()
+
+	// A new client on the same directory delivers the backlog once the API is back
+	upClient := NewMockHttpClient()
+	upClient.On("PostRaw", mock.Anything, mock.Anything, []byte(`{"n":1}`), mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	client = newSpoolTestClient(t, upClient, dir)
+	defer client.Close()
+
+	waitFor(t, func() bool { return client.SpoolStats().Replayed == 1 })
+	upClient.AssertExpectations(t)
+}
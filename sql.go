@@ -0,0 +1,88 @@
+package tinybird
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var formatClausePattern = regexp.MustCompile(`(?i)\bFORMAT\s+\w+\s*;?\s*$`)
+
+// queryHedgingName groups Query latencies for hedging
+const queryHedgingName = "sql"
+
+func (c *ClientImpl) Query(ctx context.Context, sql string, params map[string]string) (*EndpointResponse, error) {
+	fetch := func(ctx context.Context) (*EndpointResponse, error) {
+		var response EndpointResponse
+
+		if err := c.queryInto(ctx, sql, params, &response); err != nil {
+			return nil, err
+		}
+
+		return &response, nil
+	}
+
+	if c.hedger != nil {
+		return c.hedger.do(ctx, queryHedgingName, fetch)
+	}
+
+	return fetch(ctx)
+}
+
+// queryInto runs a SQL query through the Query API and unmarshals the JSON response into result
+func (c *ClientImpl) queryInto(ctx context.Context, sql string, params map[string]string, result interface{}) error {
+	query := make(map[string]string, len(params)+1)
+	for k, v := range params {
+		query[k] = v
+	}
+	query["q"] = withJSONFormat(sql)
+
+	return c.httpClient.Get(ctx, c.apiURL("sql"), query, result)
+}
+
+// apiURL builds the URL of a versioned API path such as "sql" or "jobs/{id}"
+func (c *ClientImpl) apiURL(path string) string {
+	return fmt.Sprintf("%s://%s/%s/%s",
+		c.options.Protocol,
+		c.options.Host,
+		c.options.ApiVersion,
+		path,
+	)
+}
+
+// withJSONFormat appends FORMAT JSON unless the query already sets an output format
+func withJSONFormat(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if formatClausePattern.MatchString(sql) {
+		return sql
+	}
+	return strings.TrimSuffix(sql, ";") + " FORMAT JSON"
+}
+
+// quoteString quotes s as a ClickHouse string literal
+func quoteString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
+
+// timeRange returns the conditions restricting column to [since, until), each
+// prefixed with AND. Zero times leave that end of the range open.
+func timeRange(column string, since time.Time, until time.Time) string {
+	var sb strings.Builder
+	if !since.IsZero() {
+		fmt.Fprintf(&sb, " AND %s >= toDateTime(%s)", column, quoteString(since.UTC().Format(clickHouseDateTimeLayout)))
+	}
+	if !until.IsZero() {
+		fmt.Fprintf(&sb, " AND %s < toDateTime(%s)", column, quoteString(until.UTC().Format(clickHouseDateTimeLayout)))
+	}
+	return sb.String()
+}
+
+// quoteIdentifier quotes name as a ClickHouse identifier
+func quoteIdentifier(name string) string {
+	name = strings.ReplaceAll(name, "`", "\\`")
+	return "`" + name + "`"
+}
@@ -2,6 +2,8 @@ package tinybird
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"time"
 
 	"github.com/NOLLYWOOD-COM/tinybird/internal/httpclient"
@@ -12,10 +14,20 @@ type Client interface {
 	//
 	// ctx: The context for the request.
 	//
-	// input: The input data to be analyzed, a byte slice or remote file URL.
+	// input: The input data to be analyzed: a byte slice, an io.Reader, an *os.File,
+	// a local file path, or a remote file URL such as https://example.com/data.csv.
+	//
+	// callOptions: Optional per-call overrides, such as WithTimeout or WithToken.
 	//
 	// Returns an error if the analysis fails, and an AnalyzeResponse containing the analysis results.
-	Analyze(ctx context.Context, input interface{}) (*AnalyzeResponse, error)
+	Analyze(ctx context.Context, input interface{}, callOptions ...CallOption) (*AnalyzeResponse, error)
+	// AnalyzeWithOptions analyzes the input data like Analyze, with format and CSV dialect
+	// hints and limits on the sample uploaded.
+	//
+	// options: Optional format, dialect and sample size. CSV and NDJSON readers, files
+	// and paths are cut to whole rows within DefaultAnalyzeSampleBytes by default;
+	// byte slices are uploaded whole, and as is when options is nil.
+	AnalyzeWithOptions(ctx context.Context, input interface{}, options *AnalyzeOptions, callOptions ...CallOption) (*AnalyzeResponse, error)
 	// CallEndpoint calls a Tinybird endpoint with the specified parameters.
 	//
 	// ctx: The context for the request.
@@ -24,10 +36,19 @@ type Client interface {
 	//
 	// params: A map of query parameters to include in the request.
 	//
-	// result: A pointer to a variable where the response will be unmarshaled.
+	// callOptions: Optional per-call overrides, such as WithTimeout, WithToken or WithParamMode.
 	//
 	// Returns an error if the request fails.
-	CallEndpoint(ctx context.Context, endpoint string, params map[string]string) (*EndpointResponse, error)
+	CallEndpoint(ctx context.Context, endpoint string, params map[string]string, callOptions ...CallOption) (*EndpointResponse, error)
+	// Paginate iterates over the pages of an endpoint that accepts page size and
+	// page number, row offset or cursor parameters.
+	//
+	// params: Endpoint parameters sent with every page.
+	//
+	// spec: How pages are requested; see PaginationSpec.
+	//
+	// callOptions: Optional per-call overrides applied to every page request.
+	Paginate(ctx context.Context, endpoint string, params map[string]string, spec PaginationSpec, callOptions ...CallOption) *Paginator
 	// SendEvents sends event data to the specified datasource.
 	//
 	// ctx: The context for the request.
@@ -37,19 +58,136 @@ type Client interface {
 	// data: The event data to be sent, typically in a byte slice format.
 	//
 	// options: Optional parameters for sending events, such as compression settings.
-	SendEvents(ctx context.Context, datasourceName string, data []byte, options *SendEventsOptions) (*WriteResponse, error)
+	//
+	// callOptions: Optional per-call overrides, such as WithTimeout or WithToken.
+	SendEvents(ctx context.Context, datasourceName string, data []byte, options *SendEventsOptions, callOptions ...CallOption) (*WriteResponse, error)
+	// SendEventsStream sends event data read from a stream to the specified datasource.
+	//
+	// ctx: The context for the request.
+	//
+	// datasourceName: The name of the datasource to which events will be sent.
+	//
+	// data: A reader producing the event data. Compression happens while the body is
+	// being sent, without buffering the whole payload in memory. Seekable readers are
+	// rewound on retry; other readers are spooled to a temporary file as they are read.
+	//
+	// options: Optional parameters for sending events, such as compression settings.
+	SendEventsStream(ctx context.Context, datasourceName string, data io.Reader, options *SendEventsOptions) (*WriteResponse, error)
+	// Query runs a SQL query through the Query API.
+	//
+	// ctx: The context for the request.
+	//
+	// sql: The SQL query. FORMAT JSON is appended unless the query sets a format.
+	//
+	// params: Optional query parameters, such as values for templated queries.
+	Query(ctx context.Context, sql string, params map[string]string) (*EndpointResponse, error)
+	// GetJob returns the current state of an asynchronous job.
+	GetJob(ctx context.Context, jobID string) (*Job, error)
+	// WaitForJob polls a job until it reaches a final status.
+	//
+	// pollInterval: Time between polls, DefaultJobPollInterval if zero.
+	//
+	// Returns an error wrapping ErrJobFailed if the job ends in error or is cancelled.
+	WaitForJob(ctx context.Context, jobID string, pollInterval time.Duration) (*Job, error)
+	// DeleteData deletes the rows of a datasource matching a SQL condition.
+	//
+	// Returns the asynchronous delete job.
+	DeleteData(ctx context.Context, datasourceName string, condition string) (*Job, error)
+	// QueryQuarantine returns rows from the quarantine datasource of the given datasource.
+	//
+	// options: Optional time range, filter and limit for the query.
+	QueryQuarantine(ctx context.Context, datasourceName string, options *QuarantineQueryOptions) ([]QuarantineRecord, error)
+	// ReplayQuarantine passes quarantined rows through fix and re-sends the fixed rows to the datasource.
+	//
+	// fix: Called for each quarantine record; returns the corrected row and whether to replay it.
+	//
+	// options: Optional query, send and clean-up settings.
+	ReplayQuarantine(ctx context.Context, datasourceName string, fix ReplayFunc, options *ReplayQuarantineOptions) (*ReplayQuarantineResult, error)
+	// GetCopyPipe returns a copy pipe with its target datasource and schedule.
+	GetCopyPipe(ctx context.Context, pipeName string) (*CopyPipe, error)
+	// RunCopyPipe triggers an on-demand run of a copy pipe.
+	//
+	// options: Optional parameters, copy mode and whether to wait for the job to finish.
+	//
+	// Returns the copy job, in its final state when waiting.
+	RunCopyPipe(ctx context.Context, pipeName string, options *CopyRunOptions) (*Job, error)
+	// PauseCopyPipe pauses the schedule of a copy pipe.
+	PauseCopyPipe(ctx context.Context, pipeName string) (*CopyPipe, error)
+	// ResumeCopyPipe resumes the schedule of a paused copy pipe.
+	ResumeCopyPipe(ctx context.Context, pipeName string) (*CopyPipe, error)
+	// ListCopyRuns returns the most recent copy jobs of a pipe, newest first.
+	//
+	// limit: Maximum number of jobs, 0 returns every job the API reports.
+	ListCopyRuns(ctx context.Context, pipeName string, limit int) ([]Job, error)
+	// CreateMaterialization appends a node to a pipe and materializes it into a datasource.
+	//
+	// spec: The node, its SQL, the target datasource and optional population settings.
+	//
+	// Returns the materialized node and, when populating, the populate job.
+	CreateMaterialization(ctx context.Context, pipeName string, spec MaterializationSpec) (*Materialization, error)
+	// PopulateMaterialization populates the datasource of a materialized node with existing data.
+	//
+	// options: Optional subset, condition, truncation and whether to wait for the job to finish.
+	PopulateMaterialization(ctx context.Context, pipeName string, nodeName string, options *PopulateOptions) (*Job, error)
+	// GetMaterializationStatus returns whether a node is materialized and its most recent population job.
+	GetMaterializationStatus(ctx context.Context, pipeName string, nodeName string) (*MaterializationStatus, error)
+	// Sinks returns a client for sink pipes, which export data to object storage or Kafka.
+	Sinks() SinksClient
+	// Variables returns a client for workspace environment variables and secrets.
+	Variables() VariablesClient
+	// Branches returns a client for workspace branches.
+	Branches() BranchesClient
+	// Monitoring returns a client for the service datasources reporting endpoint and ingestion health.
+	Monitoring() MonitoringClient
+	// WithCredentials returns a client that sends every request with the given token
+	// to the given host. Empty values keep the current ones. The derived client shares
+	// the configuration, connections and cache but has no spool or drift detection.
+	WithCredentials(token string, host string) Client
+	// ForBranch returns a client whose methods, such as SendEvents and CallEndpoint, target a branch.
+	ForBranch(branch *Branch) (Client, error)
+	// GetDatasourceColumns returns the column definitions of a datasource, with their JSONPaths.
+	GetDatasourceColumns(ctx context.Context, datasourceName string) ([]DatasourceColumn, error)
+	// CheckSchemaDrift compares a sample of NDJSON events with the columns of a
+	// datasource, reporting JSONPaths no column reads, values that no longer fit
+	// their column type and columns absent from every event.
+	//
+	// data: The events, as they would be passed to SendEvents.
+	//
+	// options: Optional sample size, payload format and whether to type new paths with Analyze.
+	CheckSchemaDrift(ctx context.Context, datasourceName string, data []byte, options *DriftCheckOptions) (*SchemaDrift, error)
+	// SpoolStats returns the backlog and delivery counters of the on-disk spool.
+	SpoolStats() SpoolStats
+	// CacheStats returns the hit and miss counters of the CallEndpoint response cache.
+	CacheStats() CacheStats
+	// Close stops background work, such as spool replay, and releases its resources.
+	Close() error
 }
 
 type SendEventsOptions struct {
 	Wait                bool
 	Compress            bool
 	CompressionEncoding string // "gzip" or "zstd"
+	CompressionLevel    int    // Encoder-specific compression level, 0 uses the encoder default
 	Format              string // "json" for single JSON object, empty for NDJSON (default)
+	MaxPayloadSize      int    // Maximum request body size in bytes, 0 uses DefaultMaxPayloadSize and negative disables splitting
+	Concurrency         int    // Number of chunks of a split payload sent in parallel, 0 or 1 sends them sequentially
+
+	// Validate checks every line is valid JSON before anything is sent, returning a
+	// *ValidationError that lists the offending lines. It applies to SendEvents only.
+	Validate bool
+	// Schema optionally validates each line against datasource columns, typically
+	// AnalyzeResponse.Analysis.Columns. Only used when Validate is true.
+	Schema []ColumnAnalysis
 }
 
 type ClientImpl struct {
 	httpClient httpclient.Client
 	options    *ClientOptions
+	spool      *eventSpool
+	cache      *endpointCache
+	calls      *callGroup
+	hedger     *hedger
+	drift      *driftMonitor
 }
 
 type ClientOptions struct {
@@ -60,6 +198,37 @@ type ClientOptions struct {
 	Timeout    time.Duration
 	MaxRetries int
 	RetryDelay time.Duration
+
+	// Connection pooling and transport tuning. Zero values use the net/http defaults.
+	MaxIdleConnsPerHost int           // Maximum idle connections kept per host
+	MaxConnsPerHost     int           // Maximum total connections per host, 0 means unlimited
+	IdleConnTimeout     time.Duration // How long an idle connection is kept in the pool
+	DisableHTTP2        bool          // Disable HTTP/2 negotiation
+	TCPKeepAlive        time.Duration // TCP keepalive interval, negative disables keepalives
+	TLSSessionCacheSize int           // Number of TLS sessions cached for resumption, 0 disables caching
+
+	// Durable spool for events that fail with transient errors. Disabled when SpoolDir is empty.
+	SpoolDir           string          // Directory holding the spool segment files
+	SpoolMaxBytes      int64           // Maximum disk usage of the spool, 0 means unlimited
+	SpoolRetryInterval time.Duration   // Delay between delivery attempts while the API is failing
+	SpoolErrorHandler  func(err error) // Called when spooling fails or a spooled payload is dropped
+
+	CircuitBreaker *CircuitBreakerOptions // Optional circuit breaking around API calls, disabled when nil
+	RateLimit      *RateLimitOptions      // Optional client-side rate limiting, disabled when nil
+	Cache          *CacheOptions          // Optional CallEndpoint response cache, disabled when nil
+
+	// CoalesceRequests makes concurrent CallEndpoint calls with the same endpoint and
+	// parameters share a single HTTP request.
+	CoalesceRequests bool
+
+	Hedging *HedgingOptions // Optional hedged requests for CallEndpoint and Query, disabled when nil
+
+	DriftDetection *SchemaDriftOptions // Optional schema drift checks of SendEvents payloads, disabled when nil
+
+	// EndpointPostThreshold is the encoded query string length above which CallEndpoint
+	// sends parameters as a POST JSON body. 0 uses DefaultEndpointPostThreshold and
+	// negative always uses GET unless a call asks for ParamsBody.
+	EndpointPostThreshold int
 }
 
 type Option func(*ClientOptions)
@@ -69,6 +238,17 @@ type WriteResponse struct {
 	QuarantinedRows int `json:"quarantined_rows"` // Number of rows quarantined due to errors
 }
 
+type AnalyzeOptions struct {
+	Format      string // "csv", "ndjson" or "parquet", detected from the file extension or content when empty
+	SampleBytes int    // Maximum CSV or NDJSON bytes uploaded, 0 uses DefaultAnalyzeSampleBytes for readers, files and paths and no limit for byte slices, negative uploads everything
+	SampleRows  int    // Maximum CSV or NDJSON rows uploaded, including a CSV header, 0 means no limit
+
+	// CSV dialect hints, detected by the API when empty
+	Delimiter  string // Field separator, such as ";", or "\t" for .tsv files
+	NewLine    string // Row separator, such as "\r\n"
+	EscapeChar string // Character escaping quotes inside quoted fields
+}
+
 type AnalyzeResponse struct {
 	Analysis Analysis `json:"analysis"`
 	Preview  Preview  `json:"preview"`
@@ -93,6 +273,57 @@ type Preview struct {
 	Stats Statistics                 `json:"statistics"`
 }
 
+type DatasourceColumn struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`     // ClickHouse type, such as Nullable(String)
+	JSONPath     string `json:"jsonpath"` // Where the column is read from in NDJSON events, $.<name> if empty
+	Nullable     bool   `json:"nullable"`
+	DefaultValue string `json:"default_value"` // DEFAULT expression, empty if none
+}
+
+type DriftCheckOptions struct {
+	SampleRows int    // Events compared, DefaultDriftSampleRows if zero and every event if negative
+	Format     string // "json" for a single JSON object, empty for NDJSON (default)
+	Analyze    bool   // Type new paths with Analyze instead of guessing from the values
+}
+
+type SchemaDrift struct {
+	Datasource     string
+	SampledRows    int               // Number of events compared
+	NewPaths       []DriftPath       // JSONPaths in the events that no column reads, whose data is dropped
+	TypeChanges    []DriftTypeChange // Columns with values that do not fit the column type
+	MissingColumns []DriftColumn     // Columns whose JSONPath is absent from every sampled event
+}
+
+type DriftPath struct {
+	Path string // JSONPath of the values, such as $.user.plan or $.items[:].sku
+	Type string // Suggested ClickHouse type
+	Rows int    // Number of sampled events containing the path
+}
+
+type DriftTypeChange struct {
+	Column  string
+	Path    string
+	Type    string // The column type
+	Message string // Why the first mismatching value does not fit the type
+	Rows    int    // Number of sampled events with a mismatching value
+}
+
+type DriftColumn struct {
+	Column string
+	Path   string
+	Type   string
+}
+
+type DatasourceFileOptions struct {
+	Description  string            // DESCRIPTION of the datasource
+	Engine       string            // ENGINE, DefaultDatasourceEngine if empty
+	SortingKey   string            // ENGINE_SORTING_KEY, the first date column if empty
+	PartitionKey string            // ENGINE_PARTITION_KEY, monthly on the default sorting key if empty
+	TTL          string            // ENGINE_TTL, none if empty
+	Settings     map[string]string // Other keywords, such as ENGINE_VER for ReplacingMergeTree; keys set here are not inferred
+}
+
 type FieldMeta struct {
 	Name string `json:"name"`
 	Type string `json:"type"`
@@ -110,4 +341,369 @@ type EndpointResponse struct {
 	Rows            int                      `json:"rows"`
 	RowsBeforeLimit int                      `json:"rows_before_limit_at_least"`
 	Stats           Statistics               `json:"statistics"`
+
+	rawData json.RawMessage // data as received, decoded again by DecodedRows without losing precision
+}
+
+type Job struct {
+	ID         string         `json:"id"`
+	JobID      string         `json:"job_id"`
+	Kind       string         `json:"kind"`
+	Status     string         `json:"status"` // One of the JobStatus constants
+	JobURL     string         `json:"job_url"`
+	CreatedAt  string         `json:"created_at"`
+	UpdatedAt  string         `json:"updated_at"`
+	StartedAt  string         `json:"started_at"`
+	Error      string         `json:"error"`
+	PipeName   string         `json:"pipe_name"`
+	Datasource *JobDatasource `json:"datasource"`
+}
+
+type JobDatasource struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type QuarantineQueryOptions struct {
+	Since time.Time // Only records inserted at or after this time
+	Until time.Time // Only records inserted before this time
+	Where string    // Additional SQL condition on the quarantine columns
+	Limit int       // Maximum number of records, DefaultQuarantineLimit if zero
+}
+
+type QuarantineRecord struct {
+	InsertionDate time.Time              // When the row was quarantined
+	ErrorColumns  []string               // Columns that failed to ingest
+	Errors        []string               // Error messages for each failing column
+	ImportID      string                 // Import the row belongs to, if any
+	Fields        map[string]interface{} // The original payload fields
+}
+
+// ReplayFunc fixes a quarantine record, returning the row to re-send and
+// whether the record should be replayed at all.
+type ReplayFunc func(record QuarantineRecord) (row map[string]interface{}, replay bool, err error)
+
+type ReplayQuarantineOptions struct {
+	Query          *QuarantineQueryOptions // Which quarantine records to replay
+	SendOptions    *SendEventsOptions      // Options for re-sending the fixed rows
+	DeleteReplayed bool                    // Delete replayed records from the quarantine datasource
+	WaitForDelete  bool                    // Wait for the delete jobs to finish
+}
+
+type ReplayQuarantineResult struct {
+	Replayed   int            // Records re-sent to the datasource
+	Skipped    int            // Records the callback chose not to replay
+	Write      *WriteResponse // Response from re-sending the fixed rows
+	DeleteJobs []*Job         // Jobs deleting the replayed records, if requested
+}
+
+type SpoolStats struct {
+	Enabled        bool   // Whether a spool is configured and open
+	PendingRecords int    // Payloads waiting to be delivered
+	PendingBytes   int64  // Disk space used by pending payloads
+	Segments       int    // Segment files on disk
+	Replayed       uint64 // Payloads delivered from the spool since the client was created
+	Dropped        uint64 // Payloads discarded because the API rejected them
+	Corrupt        int    // Payloads skipped since the spool was opened because they were corrupt on disk
+}
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState = httpclient.BreakerState
+
+const (
+	CircuitClosed   = httpclient.BreakerClosed   // Requests flow normally
+	CircuitOpen     = httpclient.BreakerOpen     // Requests fail fast with ErrCircuitOpen
+	CircuitHalfOpen = httpclient.BreakerHalfOpen // Probe requests test whether the API has recovered
+)
+
+type CircuitBreakerOptions struct {
+	FailureRatio     float64       // Ratio of failed requests that opens the circuit, defaults to 0.5
+	MinRequests      int           // Requests in a window before the ratio is evaluated, defaults to 10
+	Window           time.Duration // Window over which failures are counted, defaults to 30s
+	OpenTimeout      time.Duration // Time the circuit stays open before probing, defaults to 30s
+	HalfOpenRequests int           // Successful probes needed to close the circuit, defaults to 1
+	PerResource      bool          // Track each pipe and datasource separately, not just each host
+
+	// OnStateChange is called when a breaker changes state, for example to raise alerts.
+	// key identifies the host, or host and pipe/datasource when PerResource is set.
+	OnStateChange func(key string, from, to CircuitState)
+}
+
+// RateLimit is a token bucket limit: RequestsPerSecond sustained, with bursts of up to Burst requests.
+type RateLimit = httpclient.RateLimit
+
+type RateLimitOptions struct {
+	Global      *RateLimit           // Limit shared by every request made with the client's token
+	Pipes       map[string]RateLimit // Limits for CallEndpoint, keyed by pipe name
+	Datasources map[string]RateLimit // Limits for SendEvents, keyed by datasource name
+	FailFast    bool                 // Return ErrRateLimited instead of waiting for a token
+	AutoTune    bool                 // Pause requests as instructed by X-RateLimit-* and Retry-After headers
+}
+
+type CacheOptions struct {
+	Backend              Cache                    // Where responses are stored, an in-memory LRU cache if nil
+	TTL                  time.Duration            // How long responses stay fresh, 0 disables caching unless set per endpoint
+	EndpointTTLs         map[string]time.Duration // TTLs per endpoint name, overriding TTL; 0 disables caching for the endpoint
+	StaleWhileRevalidate time.Duration            // How long expired responses are still served while being refreshed
+
+	// OnEvent is called for every cacheable call, for example to export hit ratio metrics.
+	OnEvent func(event CacheEvent)
+}
+
+type CacheStats struct {
+	Enabled   bool   // Whether a response cache is configured
+	Hits      uint64 // Calls served from fresh entries
+	StaleHits uint64 // Calls served from stale entries while refreshing
+	Misses    uint64 // Calls fetched from the API and stored
+	Bypassed  uint64 // Calls that skipped the cache with WithCacheBypass
+	Errors    uint64 // Backend failures and failed background refreshes
+}
+
+// HedgingOptions configures hedged requests: when a read has not completed after a
+// delay, an identical request is sent and whichever responds first wins. Hedging
+// only applies to CallEndpoint and Query, never to writes such as SendEvents.
+type HedgingOptions struct {
+	Delay      time.Duration // Fixed delay before hedging, also used until enough latencies are observed
+	Percentile float64       // If set, e.g. 0.95, hedge after this percentile of the endpoint's observed latency
+	MinSamples int           // Latencies needed before Percentile is used, DefaultHedgingMinSamples if zero
+	MaxHedges  int           // Maximum extra requests per call, defaults to 1
+
+	// OnHedge is called whenever a hedge request is sent, with the endpoint name or "sql" for Query.
+	OnHedge func(name string)
+}
+
+// SchemaDriftOptions configures background schema drift checks of SendEvents
+// payloads. Checks run after a sampled fraction of calls, at most one at a time
+// per datasource, and never delay or fail the call.
+type SchemaDriftOptions struct {
+	SampleRate   float64       // Fraction of SendEvents calls checked, DefaultDriftSampleRate if zero
+	SampleRows   int           // Events checked per call, DefaultDriftSampleRows if zero
+	ColumnsTTL   time.Duration // How long fetched datasource columns are reused, DefaultDriftColumnsTTL if zero
+	CheckTimeout time.Duration // Time limit of each background check, DefaultDriftCheckTimeout if zero
+	Analyze      bool          // Type new paths with Analyze instead of guessing from the values
+
+	// OnDrift is called with every check that finds drift.
+	OnDrift func(drift *SchemaDrift)
+	// OnError is called when a check fails, for example when the columns cannot be fetched.
+	OnError func(err error)
+}
+
+type PaginationSpec struct {
+	Strategy      PaginationStrategy // PaginateOffset (default) or PaginateKeyset
+	PageSize      int                // Rows per page, DefaultPageSize if zero
+	PageSizeParam string             // Parameter carrying the page size, "page_size" if empty
+	MaxPages      int                // Stop after this many pages, 0 means no limit
+
+	// Offset pagination
+	PageParam   string // Parameter carrying the page number, "page" if empty
+	FirstPage   int    // Number of the first page, usually 0 or 1
+	OffsetParam string // If set, a row offset is sent in this parameter instead of a page number
+
+	// Keyset pagination
+	CursorParam   string // Parameter carrying the cursor, "cursor" if empty
+	CursorColumn  string // Column of the last row whose value is the next cursor, required
+	InitialCursor string // Cursor for the first page, none if empty
+
+	// Prefetch requests the next page while the current one is being consumed
+	Prefetch bool
+}
+
+type CopyPipe struct {
+	ID               string        `json:"id"`
+	Name             string        `json:"name"`
+	Type             string        `json:"type"`
+	TargetDatasource string        `json:"copy_target_datasource"` // ID of the datasource the pipe copies into
+	CopyMode         string        `json:"copy_mode"`              // CopyModeAppend or CopyModeReplace
+	Schedule         *CopySchedule `json:"schedule"`               // Nil for copy pipes run on demand only
+}
+
+type CopySchedule struct {
+	Cron     string `json:"cron"`
+	Timezone string `json:"timezone"`
+	Status   string `json:"status"` // CopyScheduleRunning or CopyScheduleShutdown when paused
+}
+
+type CopyRunOptions struct {
+	Params       map[string]string // Values for the pipe's template parameters
+	Mode         string            // CopyModeAppend or CopyModeReplace, the pipe's mode if empty
+	Wait         bool              // Wait for the copy job to finish
+	PollInterval time.Duration     // Interval between job polls when waiting, DefaultJobPollInterval if zero
+}
+
+type PipeNode struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	SQL          string `json:"sql"`
+	Description  string `json:"description"`
+	NodeType     string `json:"node_type"`
+	Materialized string `json:"materialized"` // ID of the datasource the node materializes into, if any
+}
+
+type MaterializationSpec struct {
+	Node        string           // Name of the node to create
+	SQL         string           // The node's query
+	Datasource  string           // Name of the existing datasource the node writes into
+	Description string           // Optional node description
+	Populate    *PopulateOptions // Populate the datasource with existing data, not populated when nil
+}
+
+type PopulateOptions struct {
+	Subset       float64       // Fraction of the source data to populate, e.g. 0.1, all data if zero
+	Condition    string        // SQL condition restricting the source rows, e.g. "date >= '2024-01-01'"
+	Truncate     bool          // Truncate the target datasource first
+	Wait         bool          // Wait for the populate job to finish
+	PollInterval time.Duration // Interval between job polls when waiting, DefaultJobPollInterval if zero
+}
+
+type Materialization struct {
+	Node PipeNode // The materialized node
+	Job  *Job     // The populate job, nil when not populating
+}
+
+type MaterializationStatus struct {
+	Node         PipeNode
+	Materialized bool   // Whether the node materializes into a datasource
+	Datasource   string // ID of the target datasource
+	LastPopulate *Job   // Most recent populate job writing to Datasource, nil if none or not materialized
+}
+
+type SinkSpec struct {
+	Connection   string // Name of the S3, GCS or Kafka connection, required
+	ScheduleCron string // Cron expression for scheduled exports, on demand only if empty
+
+	// Object storage sinks
+	Path          string // Bucket path, e.g. "s3://exports/events"
+	FileTemplate  string // File name template, e.g. "events_{date}"
+	Format        string // "csv", "ndjson" or "parquet"
+	Compression   string // "none", "gzip" or "snappy"
+	WriteStrategy string // "new" or "truncate"
+
+	// Kafka sinks
+	KafkaTopic string
+}
+
+type SinkPipe struct {
+	ID       string        `json:"id"`
+	Name     string        `json:"name"`
+	Type     string        `json:"type"`
+	Schedule *CopySchedule `json:"schedule"` // Nil for sinks run on demand only
+}
+
+type SinkRunOptions struct {
+	Params       map[string]string // Values for the pipe's template parameters
+	Wait         bool              // Wait for the export to finish
+	PollInterval time.Duration     // Interval between job polls when waiting, DefaultJobPollInterval if zero
+}
+
+type SinkRun struct {
+	Job
+	Files      []SinkFile     `json:"files"`      // Files written, for object storage sinks
+	Statistics SinkStatistics `json:"statistics"` // Totals across the export
+}
+
+type SinkFile struct {
+	Path  string `json:"path"`
+	Rows  int    `json:"rows"`
+	Bytes int64  `json:"bytes"`
+}
+
+type SinkStatistics struct {
+	Rows  int   `json:"rows"`
+	Bytes int64 `json:"bytes"`
+	Files int   `json:"files"`
+}
+
+type Variable struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"` // VariableTypeSecret
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	EditedBy  string `json:"edited_by"`
+}
+
+type VariablesSyncOptions struct {
+	DryRun bool // Compute the diff without changing anything
+	Prune  bool // Delete workspace variables missing from the desired set
+}
+
+// VariablesDiff lists the variable names a sync creates, updates and deletes. It never holds values.
+type VariablesDiff struct {
+	Create []string
+	Update []string
+	Delete []string
+}
+
+type Branch struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Token     string `json:"token"` // Admin token of the branch
+	Host      string `json:"host"`  // API host of the branch, the client's host if empty
+	CreatedAt string `json:"created_at"`
+}
+
+type BranchOptions struct {
+	LastPartition bool          // Copy the last partition of each datasource into the branch
+	Wait          bool          // Wait for the branch to be ready
+	PollInterval  time.Duration // Interval between job polls when waiting, DefaultJobPollInterval if zero
+}
+
+type MonitoringOptions struct {
+	Since      time.Time // Only rows at or after this time
+	Until      time.Time // Only rows before this time
+	Names      []string  // Only these pipes or datasources, all if empty
+	ErrorsOnly bool      // Only failed requests or operations
+	Limit      int       // Maximum number of rows, DefaultMonitoringLimit if zero
+}
+
+type PipeStats struct {
+	PipeName    string
+	Requests    int64
+	Errors      int64
+	ErrorRate   float64 // Errors divided by requests, between 0 and 1
+	AvgDuration time.Duration
+	P50Duration time.Duration
+	P95Duration time.Duration
+	P99Duration time.Duration
+	ReadBytes   int64
+	ReadRows    int64
+}
+
+type PipeRequest struct {
+	StartTime  time.Time
+	RequestID  string
+	PipeName   string
+	Duration   time.Duration
+	StatusCode int
+	Error      bool
+	ReadBytes  int64
+	ReadRows   int64
+	ResultRows int64
+	URL        string
+}
+
+type DatasourceOperation struct {
+	Timestamp      time.Time
+	EventType      string // e.g. create, append, append-hfi, replace, delete_data
+	DatasourceID   string
+	DatasourceName string
+	Result         string // OperationResultOK or OperationResultError
+	ElapsedTime    time.Duration
+	Error          string
+	Rows           int64
+	RowsQuarantine int64
+	JobID          string
+	ImportID       string
+}
+
+type BIQuery struct {
+	StartTime       time.Time
+	Query           string
+	QueryNormalized string
+	ErrorCode       int // 0 when the query succeeded
+	Error           string
+	Duration        time.Duration
+	ReadRows        int64
+	ReadBytes       int64
+	ResultRows      int64
+	ResultBytes     int64
 }
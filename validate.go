@@ -0,0 +1,346 @@
+package tinybird
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/NOLLYWOOD-COM/tinybird/chtypes"
+	"github.com/NOLLYWOOD-COM/tinybird/internal/jsonpath"
+)
+
+// maxReportedLineErrors limits how many line errors are spelled out in ValidationError.Error
+const maxReportedLineErrors = 10
+
+// ValidationError is returned by SendEvents when client-side validation rejects the payload.
+// No data is sent when validation fails.
+type ValidationError struct {
+	Lines []LineError // Every problem found, in line order
+}
+
+// LineError describes a problem found on a single line of the payload.
+type LineError struct {
+	Line    int    // One-based line number
+	Column  string // The schema column involved, empty for JSON syntax errors
+	Message string // Description of the problem
+}
+
+func (e *ValidationError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "validation failed for %d line(s)", len(e.LineNumbers()))
+
+	for i, lineErr := range e.Lines {
+		if i == maxReportedLineErrors {
+			fmt.Fprintf(&sb, "; and %d more", len(e.Lines)-maxReportedLineErrors)
+			break
+		}
+		if lineErr.Column != "" {
+			fmt.Fprintf(&sb, "; line %d column %s: %s", lineErr.Line, lineErr.Column, lineErr.Message)
+		} else {
+			fmt.Fprintf(&sb, "; line %d: %s", lineErr.Line, lineErr.Message)
+		}
+	}
+
+	return sb.String()
+}
+
+// LineNumbers returns the distinct line numbers that failed validation
+func (e *ValidationError) LineNumbers() []int {
+	var lines []int
+	for _, lineErr := range e.Lines {
+		if len(lines) == 0 || lines[len(lines)-1] != lineErr.Line {
+			lines = append(lines, lineErr.Line)
+		}
+	}
+	return lines
+}
+
+// schemaColumn is a schema column with its JSONPath parsed ahead of time
+type schemaColumn struct {
+	name     string
+	typ      *chtypes.Type
+	segments []jsonpath.Segment
+}
+
+// validateEvents checks that the payload is well formed and, if a schema is
+// given, that every line matches the column types.
+func validateEvents(data []byte, options *SendEventsOptions) error {
+	columns, err := compileSchema(options.Schema)
+	if err != nil {
+		return err
+	}
+
+	var validationErr ValidationError
+
+	if options.Format == "json" {
+		validationErr.Lines = validateLine(data, 1, true, columns)
+	} else {
+		line := 0
+		for offset := 0; offset < len(data); {
+			end := len(data)
+			if i := bytes.IndexByte(data[offset:], '\n'); i >= 0 {
+				end = offset + i
+			}
+			line++
+
+			if text := bytes.TrimSpace(data[offset:end]); len(text) > 0 {
+				validationErr.Lines = append(validationErr.Lines, validateLine(text, line, len(columns) > 0, columns)...)
+			}
+
+			offset = end + 1
+		}
+	}
+
+	if len(validationErr.Lines) > 0 {
+		return &validationErr
+	}
+
+	return nil
+}
+
+func compileSchema(schema []ColumnAnalysis) ([]schemaColumn, error) {
+	columns := make([]schemaColumn, 0, len(schema))
+
+	for _, column := range schema {
+		if column.Path == "" {
+			continue
+		}
+
+		segments, err := jsonpath.Parse(column.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema column %s: %w", column.Name, err)
+		}
+
+		typ, err := chtypes.Parse(column.RecommendedType)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema column %s: %w", column.Name, err)
+		}
+
+		columns = append(columns, schemaColumn{
+			name:     column.Name,
+			typ:      typ,
+			segments: segments,
+		})
+	}
+
+	return columns, nil
+}
+
+// validateLine decodes a single JSON document and checks it against the schema columns
+func validateLine(text []byte, line int, requireObject bool, columns []schemaColumn) []LineError {
+	decoder := json.NewDecoder(bytes.NewReader(text))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []LineError{{Line: line, Message: fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+
+	if decoder.More() {
+		return []LineError{{Line: line, Message: "expected a single JSON value"}}
+	}
+
+	if _, ok := value.(map[string]interface{}); requireObject && !ok {
+		return []LineError{{Line: line, Message: "expected a JSON object"}}
+	}
+
+	var errs []LineError
+	for _, column := range columns {
+		v, _ := jsonpath.Eval(value, column.segments)
+		if problem := checkValue(column.typ, v); problem != "" {
+			errs = append(errs, LineError{Line: line, Column: column.name, Message: problem})
+		}
+	}
+
+	return errs
+}
+
+var (
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}$`)
+
+	dateLayouts = []string{
+		"2006-01-02",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04:05.999999999",
+		time.RFC3339,
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04:05.999999999",
+	}
+)
+
+// integerBits is the size and signedness of each ClickHouse integer type
+var integerBits = map[chtypes.Kind]struct {
+	size     uint
+	unsigned bool
+}{
+	chtypes.KindInt8: {8, false}, chtypes.KindInt16: {16, false}, chtypes.KindInt32: {32, false},
+	chtypes.KindInt64: {64, false}, chtypes.KindInt128: {128, false}, chtypes.KindInt256: {256, false},
+	chtypes.KindUInt8: {8, true}, chtypes.KindUInt16: {16, true}, chtypes.KindUInt32: {32, true},
+	chtypes.KindUInt64: {64, true}, chtypes.KindUInt128: {128, true}, chtypes.KindUInt256: {256, true},
+}
+
+// checkValue reports why v cannot be stored in a column of ClickHouse type typ,
+// or an empty string if it can. A nil v stands for both null and missing values.
+func checkValue(typ *chtypes.Type, v interface{}) string {
+	switch typ.Kind {
+	case chtypes.KindLowCardinality:
+		return checkValue(typ.Elem, v)
+	case chtypes.KindNullable:
+		if v == nil {
+			return ""
+		}
+		return checkValue(typ.Elem, v)
+	}
+
+	if v == nil {
+		return fmt.Sprintf("missing value for non-nullable %s", typ)
+	}
+
+	switch typ.Kind {
+	case chtypes.KindString, chtypes.KindFixedString, chtypes.KindEnum:
+		if _, ok := v.(string); !ok {
+			return fmt.Sprintf("expected string for %s, got %s", typ, jsonKind(v))
+		}
+	case chtypes.KindUUID:
+		if s, ok := v.(string); !ok || !uuidPattern.MatchString(s) {
+			return fmt.Sprintf("expected UUID string, got %s", describeValue(v))
+		}
+	case chtypes.KindIPv4, chtypes.KindIPv6:
+		if s, ok := v.(string); !ok || net.ParseIP(s) == nil {
+			return fmt.Sprintf("expected %s address string, got %s", typ.Name, describeValue(v))
+		}
+	case chtypes.KindBool:
+		if _, ok := v.(bool); !ok {
+			return fmt.Sprintf("expected boolean, got %s", jsonKind(v))
+		}
+	case chtypes.KindInt8, chtypes.KindInt16, chtypes.KindInt32, chtypes.KindInt64, chtypes.KindInt128, chtypes.KindInt256,
+		chtypes.KindUInt8, chtypes.KindUInt16, chtypes.KindUInt32, chtypes.KindUInt64, chtypes.KindUInt128, chtypes.KindUInt256:
+		return checkInteger(typ, v)
+	case chtypes.KindFloat32, chtypes.KindFloat64, chtypes.KindDecimal:
+		if !isNumeric(v) {
+			return fmt.Sprintf("expected number for %s, got %s", typ, describeValue(v))
+		}
+	case chtypes.KindDate, chtypes.KindDate32, chtypes.KindDateTime, chtypes.KindDateTime64:
+		if !isDateValue(v) {
+			return fmt.Sprintf("expected date or timestamp for %s, got %s", typ, describeValue(v))
+		}
+	case chtypes.KindArray:
+		array, ok := v.([]interface{})
+		if !ok {
+			return fmt.Sprintf("expected array for %s, got %s", typ, jsonKind(v))
+		}
+		for i, elem := range array {
+			if problem := checkValue(typ.Elem, elem); problem != "" {
+				return fmt.Sprintf("element %d: %s", i, problem)
+			}
+		}
+	case chtypes.KindMap:
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Sprintf("expected object for %s, got %s", typ, jsonKind(v))
+		}
+		for key, elem := range object {
+			if problem := checkValue(typ.Elem, elem); problem != "" {
+				return fmt.Sprintf("key %q: %s", key, problem)
+			}
+		}
+	case chtypes.KindTuple:
+		if _, ok := v.([]interface{}); !ok {
+			return fmt.Sprintf("expected array for %s, got %s", typ, jsonKind(v))
+		}
+	}
+
+	// Types not listed above, such as JSON, are accepted as they are
+	return ""
+}
+
+// checkInteger verifies that v is an integer within the range of the given integer type
+func checkInteger(typ *chtypes.Type, v interface{}) string {
+	var text string
+	switch n := v.(type) {
+	case json.Number:
+		text = n.String()
+	case string:
+		text = n
+	default:
+		return fmt.Sprintf("expected integer for %s, got %s", typ.Name, jsonKind(v))
+	}
+
+	value, ok := new(big.Int).SetString(text, 10)
+	if !ok {
+		return fmt.Sprintf("expected integer for %s, got %s", typ.Name, describeValue(v))
+	}
+
+	bits := integerBits[typ.Kind]
+
+	var min, max *big.Int
+	if bits.unsigned {
+		min = big.NewInt(0)
+		max = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bits.size), big.NewInt(1))
+	} else {
+		min = new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), bits.size-1))
+		max = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bits.size-1), big.NewInt(1))
+	}
+
+	if value.Cmp(min) < 0 || value.Cmp(max) > 0 {
+		return fmt.Sprintf("value %s out of range for %s", text, typ.Name)
+	}
+
+	return ""
+}
+
+func isNumeric(v interface{}) bool {
+	switch n := v.(type) {
+	case json.Number:
+		return true
+	case string:
+		_, ok := new(big.Float).SetString(n)
+		return ok
+	}
+	return false
+}
+
+func isDateValue(v interface{}) bool {
+	switch d := v.(type) {
+	case json.Number:
+		// Unix timestamps
+		return true
+	case string:
+		for _, layout := range dateLayouts {
+			if _, err := time.Parse(layout, d); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "null"
+}
+
+func describeValue(v interface{}) string {
+	switch v.(type) {
+	case string, json.Number, bool:
+		return fmt.Sprintf("%s %v", jsonKind(v), v)
+	}
+	return jsonKind(v)
+}
@@ -0,0 +1,129 @@
+package tinybird
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateEvents_InvalidJSONLines(t *testing.T) {
+	data := []byte("{\"a\":1}\n{\"a\":\n\n{\"a\":3}\nnot json\n")
+
+	err := validateEvents(data, &SendEventsOptions{Validate: true})
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("error = %v, want *ValidationError", err)
+	}
+
+	if lines := validationErr.LineNumbers(); !reflect.DeepEqual(lines, []int{2, 5}) {
+		t.Errorf("LineNumbers() = %v, want [2 5]", lines)
+	}
+}
+
+func TestValidateEvents_JSONFormatRequiresSingleObject(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "single object", data: `{"a":1}`, wantErr: false},
+		{name: "array", data: `[{"a":1}]`, wantErr: true},
+		{name: "two objects", data: `{"a":1} {"a":2}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateEvents([]byte(tt.data), &SendEventsOptions{Validate: true, Format: "json"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateEvents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateEvents_Schema(t *testing.T) {
+	schema := []ColumnAnalysis{
+		{Name: "user_id", Path: "$.user.id", RecommendedType: "UInt32"},
+		{Name: "tags", Path: "$.tags[:]", RecommendedType: "Array(LowCardinality(String))"},
+		{Name: "referrer", Path: "$.referrer", RecommendedType: "Nullable(String)"},
+		{Name: "timestamp", Path: "$.ts", RecommendedType: "DateTime64(3)"},
+	}
+
+	data := []byte(strings.Join([]string{
+		`{"user":{"id":1},"tags":["a","b"],"ts":"2024-01-01 10:00:00.123"}`,
+		`{"user":{"id":-1},"tags":["a"],"ts":"2024-01-01 10:00:00"}`,
+		`{"user":{"id":2},"tags":[1],"referrer":"x","ts":"2024-01-01"}`,
+		`{"tags":[],"ts":"yesterday"}`,
+	}, "\n"))
+
+	err := validateEvents(data, &SendEventsOptions{Validate: true, Schema: schema})
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("error = %v, want *ValidationError", err)
+	}
+
+	expected := []struct {
+		line   int
+		column string
+	}{
+		{2, "user_id"},
+		{3, "tags"},
+		{4, "user_id"},
+		{4, "timestamp"},
+	}
+
+	if len(validationErr.Lines) != len(expected) {
+		t.Fatalf("got %d line errors, want %d: %v", len(validationErr.Lines), len(expected), validationErr)
+	}
+
+	for i, want := range expected {
+		got := validationErr.Lines[i]
+		if got.Line != want.line || got.Column != want.column {
+			t.Errorf("error %d = line %d column %s, want line %d column %s", i, got.Line, got.Column, want.line, want.column)
+		}
+	}
+}
+
+func TestValidateEvents_SchemaTypes(t *testing.T) {
+	schema := []ColumnAnalysis{
+		{Name: "total", Path: "$.total", RecommendedType: "SimpleAggregateFunction(sum, UInt64)"},
+		{Name: "status", Path: "$.status", RecommendedType: "Enum8('a, b' = 1, 'c' = 2)"},
+		{Name: "scores", Path: "$.scores", RecommendedType: "Map(String, Array(Nullable(Int8)))"},
+	}
+
+	valid := []byte(`{"total":"18446744073709551615","status":"c","scores":{"x":[1,null,-128]}}`)
+	if err := validateEvents(valid, &SendEventsOptions{Validate: true, Schema: schema}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalid := []byte(`{"total":-1,"status":"c","scores":{"x":[128]}}`)
+	var validationErr *ValidationError
+	if err := validateEvents(invalid, &SendEventsOptions{Validate: true, Schema: schema}); !errors.As(err, &validationErr) || len(validationErr.Lines) != 2 {
+		t.Errorf("error = %v, want errors for total and scores", err)
+	}
+
+	schema = append(schema, ColumnAnalysis{Name: "broken", Path: "$.broken", RecommendedType: "Array(String"})
+	if err := validateEvents(valid, &SendEventsOptions{Validate: true, Schema: schema}); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("error = %v, want an invalid schema column error", err)
+	}
+}
+
+func TestSendEvents_ValidationFailsBeforeSending(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	_, err := client.SendEvents(context.Background(), "events", []byte("{\"a\":1}\n{broken\n"), &SendEventsOptions{
+		Validate: true,
+	})
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("error = %v, want *ValidationError", err)
+	}
+
+	mockClient.AssertNotCalled(t, "PostRaw")
+}
@@ -0,0 +1,163 @@
+package tinybird
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// VariableTypeSecret is the type of variables read with tb_secret() in pipes.
+const VariableTypeSecret = "secret"
+
+// VariablesClient manages workspace environment variables and secrets.
+//
+// Values are write-only: the API never returns them, and this client never
+// includes them in errors, diffs or String output.
+type VariablesClient interface {
+	// List returns every variable of the workspace, without values.
+	List(ctx context.Context) ([]Variable, error)
+	// Create adds a secret variable.
+	Create(ctx context.Context, name string, value string) (*Variable, error)
+	// Update replaces the value of an existing variable.
+	Update(ctx context.Context, name string, value string) (*Variable, error)
+	// Delete removes a variable.
+	Delete(ctx context.Context, name string) error
+	// Sync makes the workspace variables match desired, a map of names to values.
+	//
+	// options: Optional dry run and pruning of variables missing from desired.
+	//
+	// Returns the changes made, or that would be made when DryRun is set.
+	Sync(ctx context.Context, desired map[string]string, options *VariablesSyncOptions) (*VariablesDiff, error)
+}
+
+type variablesClient struct {
+	client *ClientImpl
+}
+
+func (c *ClientImpl) Variables() VariablesClient {
+	return &variablesClient{client: c}
+}
+
+func (v *variablesClient) List(ctx context.Context) ([]Variable, error) {
+	var response struct {
+		Variables []Variable `json:"variables"`
+	}
+
+	if err := v.client.httpClient.Get(ctx, v.client.apiURL("variables"), nil, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Variables, nil
+}
+
+func (v *variablesClient) Create(ctx context.Context, name string, value string) (*Variable, error) {
+	form := url.Values{}
+	form.Set("name", name)
+	form.Set("value", value)
+	form.Set("type", VariableTypeSecret)
+
+	var variable Variable
+
+	err := v.client.httpClient.PostRaw(ctx, v.client.apiURL("variables"), []byte(form.Encode()), "application/x-www-form-urlencoded", "", &variable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create variable %s: %w", name, err)
+	}
+
+	return &variable, nil
+}
+
+func (v *variablesClient) Update(ctx context.Context, name string, value string) (*Variable, error) {
+	form := url.Values{}
+	form.Set("value", value)
+
+	var variable Variable
+
+	err := v.client.httpClient.PutRaw(ctx, v.client.apiURL("variables/"+url.PathEscape(name)), []byte(form.Encode()), "application/x-www-form-urlencoded", "", &variable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update variable %s: %w", name, err)
+	}
+
+	return &variable, nil
+}
+
+func (v *variablesClient) Delete(ctx context.Context, name string) error {
+	err := v.client.httpClient.Delete(ctx, v.client.apiURL("variables/"+url.PathEscape(name)), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete variable %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func (v *variablesClient) Sync(ctx context.Context, desired map[string]string, options *VariablesSyncOptions) (*VariablesDiff, error) {
+	if options == nil {
+		options = &VariablesSyncOptions{}
+	}
+
+	existing, err := v.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := diffVariables(existing, desired, options.Prune)
+	if options.DryRun {
+		return diff, nil
+	}
+
+	for _, name := range diff.Create {
+		if _, err := v.Create(ctx, name, desired[name]); err != nil {
+			return diff, err
+		}
+	}
+	for _, name := range diff.Update {
+		if _, err := v.Update(ctx, name, desired[name]); err != nil {
+			return diff, err
+		}
+	}
+	for _, name := range diff.Delete {
+		if err := v.Delete(ctx, name); err != nil {
+			return diff, err
+		}
+	}
+
+	return diff, nil
+}
+
+// diffVariables compares workspace variables with the desired names and values.
+// Values cannot be read back, so every desired variable that exists is updated.
+func diffVariables(existing []Variable, desired map[string]string, prune bool) *VariablesDiff {
+	diff := &VariablesDiff{}
+
+	current := make(map[string]bool, len(existing))
+	for _, variable := range existing {
+		current[variable.Name] = true
+
+		if _, ok := desired[variable.Name]; !ok && prune {
+			diff.Delete = append(diff.Delete, variable.Name)
+		}
+	}
+
+	for name := range desired {
+		if current[name] {
+			diff.Update = append(diff.Update, name)
+		} else {
+			diff.Create = append(diff.Create, name)
+		}
+	}
+
+	sort.Strings(diff.Create)
+	sort.Strings(diff.Update)
+	sort.Strings(diff.Delete)
+
+	return diff
+}
+
+// Empty reports whether the diff contains no changes
+func (d *VariablesDiff) Empty() bool {
+	return len(d.Create) == 0 && len(d.Update) == 0 && len(d.Delete) == 0
+}
+
+func (d *VariablesDiff) String() string {
+	return fmt.Sprintf("create %v, update %v, delete %v", d.Create, d.Update, d.Delete)
+}
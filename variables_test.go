@@ -0,0 +1,115 @@
+package tinybird
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func mockVariables(mockClient *MockHttpClient, names ...string) {
+	mockClient.On("Get",
+		mock.Anything,
+		"https://api.tinybird.co/v0/variables",
+		mock.Anything,
+		mock.Anything,
+	).Return(nil).Run(func(args mock.Arguments) {
+		response := args.Get(3).(*struct {
+			Variables []Variable `json:"variables"`
+		})
+		for _, name := range names {
+			response.Variables = append(response.Variables, Variable{Name: name, Type: VariableTypeSecret})
+		}
+	})
+}
+
+func TestVariables_SyncDryRun(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+	mockVariables(mockClient, "db_password", "old_key")
+
+	diff, err := client.Variables().Sync(context.Background(), map[string]string{
+		"db_password": "s3cret",
+		"api_key":     "k3y",
+	}, &VariablesSyncOptions{DryRun: true, Prune: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := diff.String(); got != "create [api_key], update [db_password], delete [old_key]" {
+		t.Errorf("diff = %s", got)
+	}
+
+	// Nothing but the listing is sent in a dry run
+	mockClient.AssertNumberOfCalls(t, "Get", 1)
+	mockClient.AssertNotCalled(t, "PostRaw", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestVariables_SyncApplies(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+	mockVariables(mockClient, "db_password", "old_key")
+
+	mockClient.On("PostRaw",
+		mock.Anything,
+		"https://api.tinybird.co/v0/variables",
+		[]byte("name=api_key&type=secret&value=k3y"),
+		"application/x-www-form-urlencoded",
+		"",
+		mock.AnythingOfType("*tinybird.Variable"),
+	).Return(nil)
+
+	mockClient.On("PutRaw",
+		mock.Anything,
+		"https://api.tinybird.co/v0/variables/db_password",
+		[]byte("value=s3cret"),
+		"application/x-www-form-urlencoded",
+		"",
+		mock.AnythingOfType("*tinybird.Variable"),
+	).Return(nil)
+
+	diff, err := client.Variables().Sync(context.Background(), map[string]string{
+		"db_password": "s3cret",
+		"api_key":     "k3y",
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Without Prune, variables missing from the desired set are kept
+	if len(diff.Delete) != 0 {
+		t.Errorf("Delete = %v, want none", diff.Delete)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestVariables_ErrorsOmitValues(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockClient.On("PutRaw", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("forbidden"))
+
+	_, err := client.Variables().Update(context.Background(), "db_password", "hunter2")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("error leaks the secret value: %v", err)
+	}
+}
+
+func TestVariables_Delete(t *testing.T) {
+	mockClient := NewMockHttpClient()
+	client := newTestClient(mockClient)
+
+	mockClient.On("Delete", mock.Anything, "https://api.tinybird.co/v0/variables/old_key", mock.Anything, nil).Return(nil)
+
+	if err := client.Variables().Delete(context.Background(), "old_key"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mockClient.AssertExpectations(t)
+}